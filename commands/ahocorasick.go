@@ -0,0 +1,69 @@
+package commands
+
+// ahoCorasick finds any number of literal byte patterns in streamed data
+// in a single pass, taking one transition per input byte regardless of how
+// many patterns there are.
+type ahoCorasick struct {
+	// next[state][b] is the state after consuming byte b. Failure links are
+	// already folded in, so this is a complete DFA and never needs to backtrack.
+	next [][256]int32
+	// outputs[state] is the ids of all patterns that end when reaching state,
+	// including those that are a suffix of the current state's prefix.
+	outputs [][]int
+	state   int32
+}
+
+// newAhoCorasick builds the automaton. Pattern ids reported by step are the
+// indexes into patterns. Patterns must not be empty.
+func newAhoCorasick(patterns [][]byte) *ahoCorasick {
+	a := ahoCorasick{
+		next:    make([][256]int32, 1),
+		outputs: make([][]int, 1),
+	}
+	// build trie, using 0 (the root) as the "no child" marker as no edge can lead back to the root.
+	for id, pattern := range patterns {
+		state := int32(0)
+		for _, b := range pattern {
+			if a.next[state][b] == 0 {
+				a.next = append(a.next, [256]int32{})
+				a.outputs = append(a.outputs, nil)
+				a.next[state][b] = int32(len(a.next) - 1)
+			}
+			state = a.next[state][b]
+		}
+		a.outputs[state] = append(a.outputs[state], id)
+	}
+
+	// Breadth first, compute failure links and turn missing edges into
+	// the transition the failure state would take.
+	fail := make([]int32, len(a.next))
+	queue := make([]int32, 0, len(a.next))
+	for b := 0; b < 256; b++ {
+		if child := a.next[0][b]; child != 0 {
+			queue = append(queue, child)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		// failure state is shallower, so its outputs are already complete.
+		a.outputs[state] = append(a.outputs[state], a.outputs[fail[state]]...)
+		for b := 0; b < 256; b++ {
+			child := a.next[state][b]
+			if child == 0 {
+				a.next[state][b] = a.next[fail[state]][b]
+				continue
+			}
+			fail[child] = a.next[fail[state]][b]
+			queue = append(queue, child)
+		}
+	}
+	return &a
+}
+
+// step consumes the next byte of input and returns the ids of all patterns
+// that end on it. The returned slice must not be modified.
+func (a *ahoCorasick) step(b byte) []int {
+	a.state = a.next[a.state][b]
+	return a.outputs[a.state]
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func Test_ahoCorasick_step(t *testing.T) {
+	patterns := [][]byte{[]byte("he"), []byte("she"), []byte("his"), []byte("hers"), []byte("e")}
+	a := newAhoCorasick(patterns)
+	data := []byte("ushershishe")
+	// expected ids ending at each index of data
+	expected := [][]int{
+		nil,       // u
+		nil,       // s
+		nil,       // h
+		{1, 0, 4}, // e: she, he, e
+		nil,       // r
+		{3},       // s: hers
+		nil,       // h
+		nil,       // i
+		{2},       // s: his
+		nil,       // h
+		{1, 0, 4}, // e: she, he, e
+	}
+	for i, b := range data {
+		got := a.step(b)
+		if len(got) == 0 && len(expected[i]) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("index: %d, expected ids: %v, got: %v", i, expected[i], got)
+		}
+	}
+}
+
+// Compares against a naive search of every pattern at every position.
+func Test_ahoCorasick_step_matchesNaive(t *testing.T) {
+	patterns := [][]byte{
+		[]byte("\x00\x00"), []byte("\x00\x01\x00"), []byte("ab"), []byte("abab"),
+		[]byte("b\x00"), []byte("\xff"), []byte("ab"),
+	}
+	data := []byte("abab\x00\x01\x00\x00\x00abx\xffbab\x00ab")
+	a := newAhoCorasick(patterns)
+	for i, b := range data {
+		got := map[int]bool{}
+		for _, id := range a.step(b) {
+			got[id] = true
+		}
+		for id, pattern := range patterns {
+			expected := i+1 >= len(pattern) && bytes.Equal(data[i+1-len(pattern):i+1], pattern)
+			if got[id] != expected {
+				t.Errorf("index: %d, pattern: %q, expected match: %v, got: %v", i, pattern, expected, got[id])
+			}
+		}
+	}
+}
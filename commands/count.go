@@ -45,18 +45,27 @@ func CountBytes(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInf
 
 	if !opts.Display.Quiet {
 		fmt.Fprintf(writer, "%d bytes", bytesRead)
-		kb := float64(bytesRead) / 1024
-		mb := float64(bytesRead) / (1024 * 1024)
-		gb := float64(bytesRead) / (1024 * 1024 * 1024)
-		if gb >= 1.0 {
-			fmt.Fprintf(writer, "\n%0.2f GB", gb)
-		} else if mb >= 1.0 {
-			fmt.Fprintf(writer, "\n%0.2f MB", mb)
-		} else if kb > 0.1 {
-			fmt.Fprintf(writer, "\n%0.2f KB", kb)
+		if size := HumanSize(bytesRead); size != "" {
+			fmt.Fprintf(writer, "\n%s", size)
 		}
 	} else {
 		fmt.Fprintf(writer, "%d", bytesRead)
 	}
 	return nil
 }
+
+// HumanSize formats numBytes in KB, MB or GB, whichever is largest that is
+// at least 1. Empty if less than 0.1 KB as the number of bytes says it all.
+func HumanSize(numBytes int64) string {
+	kb := float64(numBytes) / 1024
+	mb := float64(numBytes) / (1024 * 1024)
+	gb := float64(numBytes) / (1024 * 1024 * 1024)
+	if gb >= 1.0 {
+		return fmt.Sprintf("%0.2f GB", gb)
+	} else if mb >= 1.0 {
+		return fmt.Sprintf("%0.2f MB", mb)
+	} else if kb > 0.1 {
+		return fmt.Sprintf("%0.2f KB", kb)
+	}
+	return ""
+}
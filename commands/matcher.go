@@ -0,0 +1,94 @@
+package commands
+
+// bitMatcher finds patterns in streamed data one byte at a time.
+//
+// This works like KMP in that no input is ever re-read and partial matches
+// carry over between calls, but instead of a single failure-function state
+// it tracks every partial match at once as a bitset (the shift-and algorithm).
+// A plain KMP failure function can't be computed up front for patterns with
+// wildcards or byte classes: whether "a?b" can fall back to a shorter prefix after
+// matching "aa" depends on the input byte the wildcard consumed, not just the
+// pattern. Tracking all partial matches side steps that and finds every
+// occurrence (including overlapping ones) exactly once.
+//
+// Multiple patterns are handled by laying them out one after another in the
+// same bitset, each with its own start and end bit.
+type bitMatcher struct {
+	// accepts[b] has bit i set when pattern position i matches byte b.
+	accepts [256][]uint64
+	// state has bit i set when the pattern prefix ending at position i
+	// matches the most recently consumed bytes.
+	state []uint64
+	// startBits has the first position of every pattern set, as a new
+	// partial match of each pattern can begin on any byte.
+	startBits []uint64
+	// endBits has the last position of every pattern set.
+	endBits []uint64
+	// patternEnds[i] is the bit position of the last byte of pattern i.
+	patternEnds []int
+	// reused between calls to step to avoid allocating.
+	matched []int
+}
+
+func newBitMatcher(patterns [][]byteSet) *bitMatcher {
+	totalLen := 0
+	for _, pattern := range patterns {
+		totalLen += len(pattern)
+	}
+	numWords := (totalLen + 63) / 64
+	m := bitMatcher{
+		state:       make([]uint64, numWords),
+		startBits:   make([]uint64, numWords),
+		endBits:     make([]uint64, numWords),
+		patternEnds: make([]int, 0, len(patterns)),
+	}
+	for b := 0; b < 256; b++ {
+		m.accepts[b] = make([]uint64, numWords)
+	}
+	pos := 0
+	for _, pattern := range patterns {
+		m.startBits[pos/64] |= uint64(1) << uint(pos%64)
+		for _, set := range pattern {
+			bit := uint64(1) << uint(pos%64)
+			for b := 0; b < 256; b++ {
+				if set.contains(byte(b)) {
+					m.accepts[b][pos/64] |= bit
+				}
+			}
+			pos++
+		}
+		m.endBits[(pos-1)/64] |= uint64(1) << uint((pos-1)%64)
+		m.patternEnds = append(m.patternEnds, pos-1)
+	}
+	return &m
+}
+
+// step consumes the next byte of input and returns the ids (index into the
+// patterns the matcher was created with) of all patterns that end on it.
+// The returned slice is only valid until the next call to step.
+func (m *bitMatcher) step(b byte) []int {
+	m.matched = m.matched[:0]
+	accepts := m.accepts[b]
+	// Each partial match advances one position, and a new partial match
+	// starts at every byte. Only keep those where the new byte fits.
+	// NOTE: a bit shifted out of one pattern's last position into the next
+	// pattern's first position is harmless since that bit is always set anyway.
+	carry := uint64(0)
+	anyEnded := false
+	for w := range m.state {
+		next := m.state[w] >> 63
+		m.state[w] = ((m.state[w] << 1) | carry | m.startBits[w]) & accepts[w]
+		carry = next
+		if m.state[w]&m.endBits[w] != 0 {
+			anyEnded = true
+		}
+	}
+	if anyEnded {
+		for id, end := range m.patternEnds {
+			if m.state[end/64]&(uint64(1)<<uint(end%64)) != 0 {
+				m.matched = append(m.matched, id)
+			}
+		}
+	}
+	return m.matched
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jcuga/hax/eval"
+)
+
+// ex: "u32le:0xDEADBEEF", "i16be:-2", "f32:3.14". No endianness means both.
+var typedNumberRegex = regexp.MustCompile(`(?i)^([uif])(8|16|32|64)(le|be)?:(.+)$`)
+
+// encodedNumber is a typed number encoded as bytes in a given endianness.
+type encodedNumber struct {
+	value []byte
+	// "le" or "be"
+	endianness string
+}
+
+// parseTypedNumber encodes search patterns like "u32le:0xDEADBEEF" as bytes.
+// The number can be any expression supported by eval.EvalExpression, and floats
+// can also be given in decimal or exponent notation.
+// When no endianness is given, both little and big endian encodings are
+// returned unless they are identical (ex: single byte values).
+// Returns isTyped false if input isn't a typed number pattern at all. Text that
+// looks like one can be kept as text by escaping the ':' as "\x3A", ex: "u8\x3Aabc".
+func parseTypedNumber(input string) (encodings []encodedNumber, isTyped bool, err error) {
+	parts := typedNumberRegex.FindStringSubmatch(input)
+	if parts == nil {
+		return nil, false, nil
+	}
+	kind := strings.ToLower(parts[1])
+	size, _ := strconv.Atoi(parts[2])
+	size /= 8
+	endianness := strings.ToLower(parts[3])
+	numStr := parts[4]
+
+	var bits uint64
+	if kind == "f" {
+		if size != 4 && size != 8 {
+			return nil, true, fmt.Errorf("Invalid float size in %q, expect f32 or f64", input)
+		}
+		val, parseErr := strconv.ParseFloat(numStr, 64)
+		if parseErr != nil {
+			// not a plain float, try as an integer expression
+			intVal, evalErr := eval.EvalExpression(numStr)
+			if evalErr != nil {
+				return nil, true, fmt.Errorf("Failed to parse number in %q: %v (for text, escape the ':' as \\x3A)", input, evalErr)
+			}
+			val = float64(intVal)
+		}
+		if size == 4 {
+			bits = uint64(math.Float32bits(float32(val)))
+		} else {
+			bits = math.Float64bits(val)
+		}
+	} else {
+		if kind == "u" {
+			// plain literals can use the full u64 range, which expressions can't
+			if uval, parseErr := strconv.ParseUint(strings.TrimSpace(numStr), 0, 64); parseErr == nil {
+				if size < 8 && uval > uint64(1)<<uint(size*8)-1 {
+					return nil, true, fmt.Errorf("Value %d out of range for u%d", uval, size*8)
+				}
+				return encodeNumber(uval, size, endianness), true, nil
+			}
+		}
+		val, parseErr := strconv.ParseInt(strings.TrimSpace(numStr), 0, 64)
+		if parseErr != nil {
+			var evalErr error
+			val, evalErr = eval.EvalExpression(numStr)
+			if evalErr != nil {
+				return nil, true, fmt.Errorf("Failed to parse number in %q: %v (for text, escape the ':' as \\x3A)", input, evalErr)
+			}
+		}
+		if kind == "u" {
+			if val < 0 || (size < 8 && val > int64(1)<<uint(size*8)-1) {
+				return nil, true, fmt.Errorf("Value %d out of range for u%d", val, size*8)
+			}
+		} else if size < 8 {
+			limit := int64(1) << uint(size*8-1)
+			if val < -limit || val > limit-1 {
+				return nil, true, fmt.Errorf("Value %d out of range for i%d", val, size*8)
+			}
+		}
+		bits = uint64(val) // NOTE: negative values become two's complement
+	}
+	return encodeNumber(bits, size, endianness), true, nil
+}
+
+// encodeNumber gives the size lowest bytes of bits in the given endianness,
+// or both when endianness is empty and they differ.
+func encodeNumber(bits uint64, size int, endianness string) []encodedNumber {
+	le := make([]byte, 8)
+	binary.LittleEndian.PutUint64(le, bits)
+	le = le[:size]
+	be := make([]byte, size)
+	for i := range le {
+		be[i] = le[size-1-i]
+	}
+	switch endianness {
+	case "le":
+		return []encodedNumber{{le, "le"}}
+	case "be":
+		return []encodedNumber{{be, "be"}}
+	default:
+		if string(le) == string(be) {
+			return []encodedNumber{{le, "le"}}
+		}
+		return []encodedNumber{{le, "le"}, {be, "be"}}
+	}
+}
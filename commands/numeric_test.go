@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseTypedNumber(t *testing.T) {
+	type testCase struct {
+		input          string
+		expected       []encodedNumber
+		expectedTyped  bool
+		expectedErrStr string
+	}
+	cases := []testCase{
+		{"u32le:0xDEADBEEF", []encodedNumber{{[]byte{0xEF, 0xBE, 0xAD, 0xDE}, "le"}}, true, ""},
+		{"U32BE:0xDEADBEEF", []encodedNumber{{[]byte{0xDE, 0xAD, 0xBE, 0xEF}, "be"}}, true, ""},
+		{"i16be:-2", []encodedNumber{{[]byte{0xFF, 0xFE}, "be"}}, true, ""},
+		{"i8:-128", []encodedNumber{{[]byte{0x80}, "le"}}, true, ""},
+		{"u16:0x1234", []encodedNumber{{[]byte{0x34, 0x12}, "le"}, {[]byte{0x12, 0x34}, "be"}}, true, ""},
+		// same either way, so only searched once:
+		{"u16:0x4242", []encodedNumber{{[]byte{0x42, 0x42}, "le"}}, true, ""},
+		// expressions:
+		{"u32be:0x100 * 3 + 1", []encodedNumber{{[]byte{0x00, 0x00, 0x03, 0x01}, "be"}}, true, ""},
+		{"i64le:1<<40", []encodedNumber{{[]byte{0, 0, 0, 0, 0, 1, 0, 0}, "le"}}, true, ""},
+		// full 64 bit ranges:
+		{"u64le:0xFFFFFFFFFFFFFFFF", []encodedNumber{{[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, "le"}}, true, ""},
+		{"u64be:18446744073709551615", []encodedNumber{{[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, "be"}}, true, ""},
+		{"u64be:0x8000000000000000", []encodedNumber{{[]byte{0x80, 0, 0, 0, 0, 0, 0, 0}, "be"}}, true, ""},
+		{"i64be:9223372036854775807", []encodedNumber{{[]byte{0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, "be"}}, true, ""},
+		{"i64be:-9223372036854775808", []encodedNumber{{[]byte{0x80, 0, 0, 0, 0, 0, 0, 0}, "be"}}, true, ""},
+		{"i64le:-0x8000000000000000", []encodedNumber{{[]byte{0, 0, 0, 0, 0, 0, 0, 0x80}, "le"}}, true, ""},
+		{"f32le:3.14", []encodedNumber{{[]byte{0xC3, 0xF5, 0x48, 0x40}, "le"}}, true, ""},
+		{"f64be:-2", []encodedNumber{{[]byte{0xC0, 0, 0, 0, 0, 0, 0, 0}, "be"}}, true, ""},
+		{"f32be:1e3", []encodedNumber{{[]byte{0x44, 0x7A, 0x00, 0x00}, "be"}}, true, ""},
+		{"f32be:0x10+6", []encodedNumber{{[]byte{0x41, 0xB0, 0x00, 0x00}, "be"}}, true, ""},
+		// not typed numbers:
+		{"hello", nil, false, ""},
+		{"u32le", nil, false, ""},
+		{"u24le:1", nil, false, ""},
+		{"u8\\x3A12", nil, false, ""},
+		// errors:
+		{"u8:256", nil, true, "Value 256 out of range for u8"},
+		{"u16le:-1", nil, true, "Value -1 out of range for u16"},
+		{"u32:0x100000000", nil, true, "Value 4294967296 out of range for u32"},
+		{"u64:-1", nil, true, "Value -1 out of range for u64"},
+		{"i8:128", nil, true, "Value 128 out of range for i8"},
+		{"i16:-32769", nil, true, "Value -32769 out of range for i16"},
+		{"f8:1", nil, true, "Invalid float size in \"f8:1\", expect f32 or f64"},
+		{"u32:zzz", nil, true, "Failed to parse number in \"u32:zzz\": strconv.ParseInt: parsing \"zzz\": invalid syntax (for text, escape the ':' as \\x3A)"},
+	}
+	for _, c := range cases {
+		encodings, isTyped, err := parseTypedNumber(c.input)
+		if err == nil && c.expectedErrStr != "" {
+			t.Errorf("input: %q, err nil, but expected: %q", c.input, c.expectedErrStr)
+		}
+		if err != nil && err.Error() != c.expectedErrStr {
+			t.Errorf("input: %q, unexpected err, expected: %q, got: %q", c.input, c.expectedErrStr, err.Error())
+		}
+		if isTyped != c.expectedTyped {
+			t.Errorf("input: %q, unexpected isTyped, expected: %v, got: %v", c.input, c.expectedTyped, isTyped)
+		}
+		if !reflect.DeepEqual(encodings, c.expected) {
+			t.Errorf("input: %q, unexpected encodings, expected: %v, got: %v", c.input, c.expected, encodings)
+		}
+	}
+}
+
+func Test_NewMultiSearcher_typedNumbers(t *testing.T) {
+	// escaped ':' is text, not a number
+	escaped, err := NewSearcher("u8\\x3A12", 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected err creating searcher: %s", err)
+	}
+	escaped.update([]byte("\x0Cu8:12"))
+	if starts := matchStarts(escaped.matches); !reflect.DeepEqual(starts, []int{1}) {
+		t.Errorf("Expected escaped typed number to match text at 1, got: %v", starts)
+	}
+
+	s, err := NewMultiSearcher([]string{"u16:0x1234", "x"}, 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected err creating searcher: %s", err)
+	}
+	s.update([]byte("\x12\x34x\x34\x12"))
+	expectedLabels := []string{"u16:0x1234 (be)", "x", "u16:0x1234 (le)"}
+	labels := make([]string, 0)
+	for _, m := range s.matches {
+		labels = append(labels, s.label(m))
+	}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Errorf("Unexpected match labels, expected: %q, got: %q", expectedLabels, labels)
+	}
+}
@@ -0,0 +1,313 @@
+package commands
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+	"github.com/jcuga/hax/util"
+)
+
+const patchUsage = `Usage: hax --file <file> --offset <offset> [--input <mode>] patch [options] <data>
+       hax --file <file> --range <start:end> patch [options] <data>
+       hax --file <file> patch --undo
+Overwrites bytes of file at offset with data, in place. The file's size never changes.
+data is parsed using --input mode (ex: hex, base64) when given, otherwise as exact bytes
+using escapes like \xAB or a typed number like u32le:0x1234 (escape the ':' as \x3A for text like
+that). --str can be used instead of <data>.
+The original bytes are recorded in a journal file so patches can be undone, newest first.
+options:
+  --undo	restore the bytes changed by the most recent patch
+  --journal <file>	journal file to use (default: <file>.hax-journal)
+  --no-journal	don't record the patch, it can't be undone
+  --backup	also copy the whole file to <file>.bak before patching, if it doesn't exist yet`
+
+const patchJournalHeader = "# hax patch journal. Each line: offset original-bytes patched-bytes\n"
+
+// patchEntry is one patch recorded in the journal.
+type patchEntry struct {
+	offset   int64
+	original []byte
+	patched  []byte
+}
+
+func (e patchEntry) String() string {
+	return fmt.Sprintf("0x%X %X %X", e.offset, e.original, e.patched)
+}
+
+func parsePatchEntry(line string) (patchEntry, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return patchEntry{}, fmt.Errorf("Invalid journal entry: %q", line)
+	}
+	offset, err := strconv.ParseInt(fields[0], 0, 64)
+	if err != nil {
+		return patchEntry{}, fmt.Errorf("Invalid offset in journal entry: %q", line)
+	}
+	original, err := hex.DecodeString(fields[1])
+	if err != nil {
+		return patchEntry{}, fmt.Errorf("Invalid original bytes in journal entry: %q", line)
+	}
+	patched, err := hex.DecodeString(fields[2])
+	if err != nil || len(patched) != len(original) {
+		return patchEntry{}, fmt.Errorf("Invalid patched bytes in journal entry: %q", line)
+	}
+	return patchEntry{offset, original, patched}, nil
+}
+
+// Patch overwrites part of opts.Filename in place, or with --undo restores
+// the last patch recorded in the file's journal.
+// Unlike other commands there is no input reader: the file is modified directly.
+func Patch(writer io.Writer, opts options.Options, cmdOptions []string) error {
+	flags := flag.NewFlagSet("patch", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard) // we report errors along with our own usage text
+	undo := flags.Bool("undo", false, "")
+	journal := flags.String("journal", "", "")
+	noJournal := flags.Bool("no-journal", false, "")
+	backup := flags.Bool("backup", false, "")
+	if err := flags.Parse(cmdOptions); err != nil {
+		return fmt.Errorf("%v\n%s", err, patchUsage)
+	}
+	if len(opts.Filename) == 0 {
+		return fmt.Errorf("patch requires --file\n%s", patchUsage)
+	}
+	if len(opts.Ranges) > 1 {
+		return fmt.Errorf("patch takes a single --offset or --range, got %d ranges", len(opts.Ranges))
+	}
+	if len(*journal) == 0 {
+		*journal = opts.Filename + ".hax-journal"
+	}
+
+	if *undo {
+		if flags.NArg() > 0 {
+			return fmt.Errorf("Unexpected args with --undo: %q", flags.Args())
+		}
+		return undoPatch(writer, opts, *journal)
+	}
+
+	// no default offset, patching the start of the file by mistake is too easy.
+	if !opts.OffsetGiven {
+		return fmt.Errorf("patch requires --offset or --range\n%s", patchUsage)
+	}
+
+	var rawData string
+	switch {
+	case flags.NArg() == 1 && len(opts.InputData) == 0:
+		rawData = flags.Arg(0)
+	case flags.NArg() == 0 && len(opts.InputData) > 0:
+		rawData = opts.InputData
+	default:
+		return fmt.Errorf("Expect patch data as either a single arg or --str\n%s", patchUsage)
+	}
+	data, err := parsePatchData(rawData, opts)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(opts.Filename, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// negative offset is relative to the end of the file
+	if opts.Offset < 0 {
+		if opts.Offset+info.Size() < 0 {
+			return fmt.Errorf("Offset %d is before the start of the file (%d bytes)", opts.Offset, info.Size())
+		}
+		opts.Offset += info.Size()
+	}
+	if opts.Offset+int64(len(data)) > info.Size() {
+		return fmt.Errorf("Patch of %d bytes at offset 0x%X goes past end of file (%d bytes)",
+			len(data), opts.Offset, info.Size())
+	}
+	// must also fit in what --limit or --range selected, same as when reading.
+	// TrimEnd is from a negative --limit or end-relative --range end.
+	selectedEnd := info.Size() - opts.TrimEnd
+	if opts.Limit < selectedEnd-opts.Offset {
+		selectedEnd = opts.Offset + opts.Limit
+	}
+	if opts.Offset+int64(len(data)) > selectedEnd {
+		selected := selectedEnd - opts.Offset
+		if selected < 0 {
+			selected = 0
+		}
+		return fmt.Errorf("Patch of %d bytes at offset 0x%X is longer than the %d bytes selected by --limit or --range",
+			len(data), opts.Offset, selected)
+	}
+	original := make([]byte, len(data))
+	if _, err := f.ReadAt(original, opts.Offset); err != nil {
+		return fmt.Errorf("Error reading data: %v", err)
+	}
+	entry := patchEntry{opts.Offset, original, data}
+
+	fmt.Fprintf(writer, "%s at offset 0x%X:\n  original: %s\n  patched:  %s\n",
+		opts.Filename, opts.Offset, previewBytes(original), previewBytes(data))
+	if !opts.Yes && !util.PromptForYes(fmt.Sprintf("Patch %d bytes?", len(data))) {
+		return errors.New("Patch cancelled")
+	}
+
+	if *backup {
+		if err := backupFile(opts.Filename, opts.Filename+".bak"); err != nil {
+			return err
+		}
+	}
+	var entries []patchEntry
+	if !*noJournal {
+		// record before writing so an interrupted patch can still be undone.
+		entries, err = readPatchJournal(*journal)
+		if err != nil {
+			return err
+		}
+		if err := writePatchJournal(*journal, append(entries, entry)); err != nil {
+			return err
+		}
+	}
+	if _, err := f.WriteAt(data, opts.Offset); err != nil {
+		if !*noJournal {
+			writePatchJournal(*journal, entries)
+		}
+		return fmt.Errorf("Error writing patch: %v", err)
+	}
+	fmt.Fprintf(writer, "Patched %d bytes.\n", len(data))
+	return nil
+}
+
+// parsePatchData parses data per opts.InputMode, with raw meaning exact bytes
+// with escape sequences, see parseReplacement.
+func parsePatchData(rawData string, opts options.Options) ([]byte, error) {
+	var data []byte
+	var err error
+	if opts.InputMode == options.Raw {
+		data, err = parseReplacement(rawData)
+	} else {
+		dataOpts := options.Options{InputData: rawData, InputMode: opts.InputMode, Limit: math.MaxInt64}
+		var reader *input.FixedLengthBufferedReader
+		reader, _, _, err = input.GetInput(&dataOpts)
+		if err == nil {
+			data, err = ioutil.ReadAll(reader)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid patch data %q: %v", rawData, err)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("Empty patch data")
+	}
+	return data, nil
+}
+
+func undoPatch(writer io.Writer, opts options.Options, journal string) error {
+	entries, err := readPatchJournal(journal)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("Nothing to undo, no patches in journal: %q", journal)
+	}
+	entry := entries[len(entries)-1]
+
+	f, err := os.OpenFile(opts.Filename, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	current := make([]byte, len(entry.patched))
+	if _, err := f.ReadAt(current, entry.offset); err != nil {
+		return fmt.Errorf("Error reading data: %v", err)
+	}
+	fmt.Fprintf(writer, "%s at offset 0x%X:\n  current:  %s\n  restored: %s\n",
+		opts.Filename, entry.offset, previewBytes(current), previewBytes(entry.original))
+	if string(current) != string(entry.patched) {
+		fmt.Fprintf(writer, "  NOTE: current bytes differ from what was patched: %s\n", previewBytes(entry.patched))
+	}
+	if !opts.Yes && !util.PromptForYes(fmt.Sprintf("Restore %d bytes?", len(entry.original))) {
+		return errors.New("Undo cancelled")
+	}
+	if _, err := f.WriteAt(entry.original, entry.offset); err != nil {
+		return fmt.Errorf("Error writing data: %v", err)
+	}
+	if len(entries) == 1 {
+		if err := os.Remove(journal); err != nil {
+			return err
+		}
+	} else if err := writePatchJournal(journal, entries[:len(entries)-1]); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Restored %d bytes.\n", len(entry.original))
+	return nil
+}
+
+// readPatchJournal gives all entries in the journal, oldest first.
+// A missing journal has no entries.
+func readPatchJournal(journal string) ([]patchEntry, error) {
+	contents, err := ioutil.ReadFile(journal)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []patchEntry
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		entry, err := parsePatchEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", journal, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func writePatchJournal(journal string, entries []patchEntry) error {
+	var b strings.Builder
+	b.WriteString(patchJournalHeader)
+	for _, entry := range entries {
+		b.WriteString(entry.String())
+		b.WriteString("\n")
+	}
+	if err := ioutil.WriteFile(journal, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("Error writing patch journal: %v", err)
+	}
+	return nil
+}
+
+// backupFile copies filename to backup unless backup already exists, in
+// which case it's assumed to be from before any patches and is kept as-is.
+func backupFile(filename, backup string) error {
+	if _, err := os.Stat(backup); err == nil {
+		return nil
+	}
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(backup, contents, 0644); err != nil {
+		return fmt.Errorf("Error writing backup: %v", err)
+	}
+	return nil
+}
+
+// previewBytes formats data as hex, eliding anything past the first 32 bytes.
+func previewBytes(data []byte) string {
+	if len(data) > 32 {
+		return fmt.Sprintf("% X ... (%d bytes)", data[:32], len(data))
+	}
+	return fmt.Sprintf("% X", data)
+}
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/options"
+)
+
+func Test_Patch_thenUndo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hax_patch")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fw.bin")
+	if err := ioutil.WriteFile(filename, []byte("0123456789abcdef"), 0644); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	checkContents := func(expected string) {
+		t.Helper()
+		contents, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("Failed to read temp file: %v", err)
+		}
+		if string(contents) != expected {
+			t.Errorf("Unexpected file contents, expected: %q, got: %q", expected, string(contents))
+		}
+	}
+
+	var writer strings.Builder
+	opts := options.Options{Filename: filename, Offset: 4, OffsetGiven: true, Limit: math.MaxInt64, InputMode: options.Raw, Yes: true}
+	if err := Patch(&writer, opts, []string{"\\xDE\\xAD"}); err != nil {
+		t.Fatalf("Unexpected err: %v", err)
+	}
+	checkContents("0123\xDE\xAD6789abcdef")
+
+	opts = options.Options{Filename: filename, Offset: 14, OffsetGiven: true, Limit: math.MaxInt64, InputMode: options.Hex, InputData: "BEEF", Yes: true}
+	if err := Patch(&writer, opts, []string{"--backup"}); err != nil {
+		t.Fatalf("Unexpected err: %v", err)
+	}
+	checkContents("0123\xDE\xAD6789abcd\xBE\xEF")
+	backup, err := ioutil.ReadFile(filename + ".bak")
+	if err != nil || string(backup) != "0123\xDE\xAD6789abcdef" {
+		t.Errorf("Unexpected backup: %q, err: %v", string(backup), err)
+	}
+
+	entries, err := readPatchJournal(filename + ".hax-journal")
+	if err != nil {
+		t.Fatalf("Unexpected err reading journal: %v", err)
+	}
+	expectedEntries := []patchEntry{
+		{4, []byte("45"), []byte("\xDE\xAD")},
+		{14, []byte("ef"), []byte("\xBE\xEF")},
+	}
+	if !reflect.DeepEqual(entries, expectedEntries) {
+		t.Errorf("Unexpected journal entries, expected: %v, got: %v", expectedEntries, entries)
+	}
+
+	opts = options.Options{Filename: filename, Yes: true}
+	if err := Patch(&writer, opts, []string{"--undo"}); err != nil {
+		t.Fatalf("Unexpected err: %v", err)
+	}
+	checkContents("0123\xDE\xAD6789abcdef")
+	if err := Patch(&writer, opts, []string{"--undo"}); err != nil {
+		t.Fatalf("Unexpected err: %v", err)
+	}
+	checkContents("0123456789abcdef")
+	if _, err := os.Stat(filename + ".hax-journal"); !os.IsNotExist(err) {
+		t.Errorf("Expected journal to be removed once empty, got: %v", err)
+	}
+	if err := Patch(&writer, opts, []string{"--undo"}); err == nil {
+		t.Errorf("Expected err with nothing to undo")
+	}
+
+	// can't grow the file
+	opts = options.Options{Filename: filename, Offset: 15, OffsetGiven: true, Limit: math.MaxInt64, InputMode: options.Raw, Yes: true}
+	err = Patch(&writer, opts, []string{"ab"})
+	expectedErr := "Patch of 2 bytes at offset 0xF goes past end of file (16 bytes)"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Unexpected err, expected: %q, got: %v", expectedErr, err)
+	}
+	checkContents("0123456789abcdef")
+
+	// offset must be given, even if it's 0
+	opts = options.Options{Filename: filename, InputMode: options.Raw, Yes: true}
+	err = Patch(&writer, opts, []string{"ab"})
+	if err == nil || !strings.HasPrefix(err.Error(), "patch requires --offset or --range") {
+		t.Errorf("Unexpected err, expected --offset required, got: %v", err)
+	}
+	checkContents("0123456789abcdef")
+}
+
+func Test_Patch_selectedRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hax_patch")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fw.bin")
+	original := "0123456789abcdef"
+
+	type testCase struct {
+		name        string
+		opts        options.Options
+		data        string
+		expected    string
+		expectedErr string
+	}
+	cases := []testCase{
+		// --range 4:+2
+		{"range length", options.Options{Offset: 4, Limit: 2}, "ABCDE", original,
+			"Patch of 5 bytes at offset 0x4 is longer than the 2 bytes selected by --limit or --range"},
+		{"range length fits", options.Options{Offset: 4, Limit: 2}, "AB", "0123AB6789abcdef", ""},
+		// --offset 14 --limit 1
+		{"limit", options.Options{Offset: 14, Limit: 1}, "AB", original,
+			"Patch of 2 bytes at offset 0xE is longer than the 1 bytes selected by --limit or --range"},
+		// --offset -4 --limit 3
+		{"negative offset and limit", options.Options{Offset: -4, Limit: 3}, "ABCD", original,
+			"Patch of 4 bytes at offset 0xC is longer than the 3 bytes selected by --limit or --range"},
+		// --offset 10 --limit -4
+		{"negative limit", options.Options{Offset: 10, Limit: math.MaxInt64, TrimEnd: 4}, "ABC", original,
+			"Patch of 3 bytes at offset 0xA is longer than the 2 bytes selected by --limit or --range"},
+		{"negative limit fits", options.Options{Offset: 10, Limit: math.MaxInt64, TrimEnd: 4}, "AB", "0123456789ABcdef", ""},
+		// --range 4:-2
+		{"end relative range", options.Options{Offset: 4, Limit: math.MaxInt64, TrimEnd: 2}, "ABCDEFGHIJKL", original,
+			"Patch of 12 bytes at offset 0x4 is longer than the 10 bytes selected by --limit or --range"},
+		// --range -6:-4
+		{"end relative start and end", options.Options{Offset: -6, Limit: math.MaxInt64, TrimEnd: 4}, "ABC", original,
+			"Patch of 3 bytes at offset 0xA is longer than the 2 bytes selected by --limit or --range"},
+		{"end before offset", options.Options{Offset: 14, Limit: math.MaxInt64, TrimEnd: 4}, "A", original,
+			"Patch of 1 bytes at offset 0xE is longer than the 0 bytes selected by --limit or --range"},
+		{"limit past end of file", options.Options{Offset: 14, Limit: 10}, "ABC", original,
+			"Patch of 3 bytes at offset 0xE goes past end of file (16 bytes)"},
+	}
+	for _, c := range cases {
+		if err := ioutil.WriteFile(filename, []byte(original), 0644); err != nil {
+			t.Fatalf("Failed to write temp file: %v", err)
+		}
+		opts := c.opts
+		opts.Filename, opts.OffsetGiven, opts.InputMode, opts.Yes = filename, true, options.Raw, true
+		err := Patch(&strings.Builder{}, opts, []string{"--no-journal", c.data})
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: unexpected err: %v", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected err: %q, got: %v", c.name, c.expectedErr, err)
+		}
+		contents, err := ioutil.ReadFile(filename)
+		if err != nil || string(contents) != c.expected {
+			t.Errorf("%s: expected file contents: %q, got: %q, err: %v", c.name, c.expected, contents, err)
+		}
+	}
+}
+
+func Test_parsePatchEntry(t *testing.T) {
+	entry := patchEntry{0x1F, []byte{0x00, 0x01}, []byte{0xFF, 0xFE}}
+	parsed, err := parsePatchEntry(entry.String())
+	if err != nil {
+		t.Fatalf("Unexpected err: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, entry) {
+		t.Errorf("Unexpected entry, expected: %v, got: %v", entry, parsed)
+	}
+	for _, line := range []string{"0x1F 0001", "zz 0001 FFFE", "0x1F 0001 FF"} {
+		if _, err := parsePatchEntry(line); err == nil {
+			t.Errorf("line: %q, expected err", line)
+		}
+	}
+}
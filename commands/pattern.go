@@ -0,0 +1,249 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+)
+
+// byteSet is the set of byte values a single position of a search pattern matches.
+type byteSet [4]uint64
+
+func (b *byteSet) add(val byte) {
+	b[val/64] |= uint64(1) << (val % 64)
+}
+
+func (b *byteSet) addRange(low, high byte) {
+	for val := int(low); val <= int(high); val++ {
+		b.add(byte(val))
+	}
+}
+
+func (b byteSet) contains(val byte) bool {
+	return b[val/64]&(uint64(1)<<(val%64)) != 0
+}
+
+func (b byteSet) size() int {
+	return bits.OnesCount64(b[0]) + bits.OnesCount64(b[1]) + bits.OnesCount64(b[2]) + bits.OnesCount64(b[3])
+}
+
+func (b byteSet) negate() byteSet {
+	return byteSet{^b[0], ^b[1], ^b[2], ^b[3]}
+}
+
+// literal returns the only byte in the set, and false if the set matches more than one value.
+func (b byteSet) literal() (byte, bool) {
+	if b.size() != 1 {
+		return 0, false
+	}
+	for i, word := range b {
+		if word != 0 {
+			return byte(i*64 + bits.TrailingZeros64(word)), true
+		}
+	}
+	return 0, false
+}
+
+func singleByte(val byte) byteSet {
+	var b byteSet
+	b.add(val)
+	return b
+}
+
+// maskedByte gives all values where the bits set in mask equal those in value.
+func maskedByte(value, mask byte) byteSet {
+	var b byteSet
+	for val := 0; val < 256; val++ {
+		if byte(val)&mask == value&mask {
+			b.add(byte(val))
+		}
+	}
+	return b
+}
+
+var anyByteSet = byteSet{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+
+// parseSearchPattern parses a pattern into what each position matches.
+// Supports:
+//
+//	literal bytes and escape sequences: \xAB \n \t \r \\ \? \[ \] \{ \}
+//	'?' wildcard which matches any byte
+//	nibble wildcards: \x4? matches 0x40-0x4F, \x?F matches 0x0F, 0x1F, ... 0xFF
+//	byte classes: [abc] [\x30-\x39] [\x00\x80-\xFF], [^...] matches anything not listed
+//	value/mask pairs: {40/F0} matches bytes where (byte & 0xF0) == 0x40
+func parseSearchPattern(inputPattern string) ([]byteSet, error) {
+	if len(inputPattern) == 0 {
+		return nil, errors.New("empty pattern")
+	}
+	pattern := make([]byteSet, 0, len(inputPattern))
+
+	for i := 0; i < len(inputPattern); {
+		set, consumed, err := parsePatternElement(inputPattern[i:])
+		if err != nil {
+			return nil, err
+		}
+		pattern = append(pattern, set)
+		i += consumed
+	}
+
+	if err := checkNotAllWildcards(pattern); err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
+// checkNotAllWildcards returns an error if a pattern is entirely anyByte as
+// that would match all bytes of input every time and is silly.
+func checkNotAllWildcards(pattern []byteSet) error {
+	for _, set := range pattern {
+		if set != anyByteSet {
+			return nil
+		}
+	}
+	return errors.New("pattern cannot be all '?' (match any byte)")
+}
+
+// parsePatternElement parses the single pattern element at the start of input
+// (a byte, escape sequence, wildcard, class etc).
+// Returns what it matches and how many bytes of input it took up.
+func parsePatternElement(input string) (byteSet, int, error) {
+	switch input[0] {
+	case '\\':
+		return parseEscape(input)
+	case '?': // handle wildcard/match-any-single-byte
+		return anyByteSet, 1, nil
+	case '[':
+		return parseByteClass(input)
+	case '{':
+		return parseValueMask(input)
+	default: // take char as-is
+		return singleByte(input[0]), 1, nil
+	}
+}
+
+// parseEscape parses the escape sequence at the start of input, which begins with '\'.
+// Returns what it matches and how many bytes of input it took up.
+func parseEscape(input string) (byteSet, int, error) {
+	if len(input) < 2 {
+		return byteSet{}, 0, errors.New("Trailing '\\'")
+	}
+	switch input[1] {
+	case 'n', 'N':
+		return singleByte('\n'), 2, nil
+	case 't', 'T':
+		return singleByte('\t'), 2, nil
+	case 'r', 'R':
+		return singleByte('\r'), 2, nil
+	case '?', '\\', '[', ']', '{', '}', '-', '^': // otherwise special chars
+		return singleByte(input[1]), 2, nil
+	case 'x', 'X':
+		if len(input) < 4 {
+			return byteSet{}, 0, errors.New("'\\x' without trailing 2 char hex")
+		}
+		high, highOk := parseNibble(input[2])
+		low, lowOk := parseNibble(input[3])
+		if !highOk || !lowOk {
+			return byteSet{}, 0, fmt.Errorf("'Invalid hex sequence: '\\x%s'", input[2:4])
+		}
+		// a '?' nibble is a wildcard, ex: \x4? matches 0x40 thru 0x4F
+		mask := byte(0)
+		if input[2] != '?' {
+			mask |= 0xF0
+		}
+		if input[3] != '?' {
+			mask |= 0x0F
+		}
+		return maskedByte(high<<4|low, mask), 4, nil
+	default:
+		return byteSet{}, 0, fmt.Errorf("Invalid escape sequence: '%s'", input[:2])
+	}
+}
+
+// parseNibble parses a single hex digit, with '?' being allowed as a wildcard (value 0).
+func parseNibble(c byte) (byte, bool) {
+	if c == '?' {
+		return 0, true
+	}
+	val, err := strconv.ParseUint(string(c), 16, 8)
+	return byte(val), err == nil
+}
+
+// parseClassByte parses a single, exact byte within a byte class.
+func parseClassByte(input string) (byte, int, error) {
+	if input[0] != '\\' {
+		return input[0], 1, nil
+	}
+	set, consumed, err := parseEscape(input)
+	if err != nil {
+		return 0, 0, err
+	}
+	val, ok := set.literal()
+	if !ok {
+		return 0, 0, fmt.Errorf("Wildcards not allowed in byte class: '%s'", input[:consumed])
+	}
+	return val, consumed, nil
+}
+
+// parseByteClass parses a class like "[a-z\x00]" at the start of input.
+// Returns what it matches and how many bytes of input it took up.
+func parseByteClass(input string) (byteSet, int, error) {
+	var set byteSet
+	i := 1 // skip '['
+	negated := false
+	if i < len(input) && input[i] == '^' {
+		negated = true
+		i++
+	}
+	empty := true
+	for {
+		if i >= len(input) {
+			return byteSet{}, 0, errors.New("Missing closing ']' for byte class")
+		}
+		if input[i] == ']' {
+			break
+		}
+		low, consumed, err := parseClassByte(input[i:])
+		if err != nil {
+			return byteSet{}, 0, err
+		}
+		i += consumed
+		high := low
+		if i+1 < len(input) && input[i] == '-' && input[i+1] != ']' {
+			high, consumed, err = parseClassByte(input[i+1:])
+			if err != nil {
+				return byteSet{}, 0, err
+			}
+			if high < low {
+				return byteSet{}, 0, fmt.Errorf("Invalid byte class range: %#02x-%#02x", low, high)
+			}
+			i += 1 + consumed
+		}
+		set.addRange(low, high)
+		empty = false
+	}
+	if empty {
+		return byteSet{}, 0, errors.New("Empty byte class: '[]'")
+	}
+	if negated {
+		set = set.negate()
+	}
+	return set, i + 1, nil
+}
+
+// parseValueMask parses "{VV/MM}" at the start of input where VV and MM are hex bytes.
+// Returns what it matches and how many bytes of input it took up.
+func parseValueMask(input string) (byteSet, int, error) {
+	if len(input) < 7 || input[3] != '/' || input[6] != '}' {
+		return byteSet{}, 0, errors.New("Expected value/mask of form: {VV/MM}, ex: {40/F0}")
+	}
+	value, err := strconv.ParseUint(input[1:3], 16, 8)
+	if err != nil {
+		return byteSet{}, 0, fmt.Errorf("Invalid value in value/mask: %q", input[:7])
+	}
+	mask, err := strconv.ParseUint(input[4:6], 16, 8)
+	if err != nil {
+		return byteSet{}, 0, fmt.Errorf("Invalid mask in value/mask: %q", input[:7])
+	}
+	return maskedByte(byte(value), byte(mask)), 7, nil
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"testing"
+)
+
+// setBytes returns all values in a set, in order.
+func setBytes(set byteSet) []byte {
+	vals := make([]byte, 0)
+	for b := 0; b < 256; b++ {
+		if set.contains(byte(b)) {
+			vals = append(vals, byte(b))
+		}
+	}
+	return vals
+}
+
+func Test_parseSearchPattern(t *testing.T) {
+	type testCase struct {
+		inputPattern string
+		// what each position of the pattern should match
+		expected       []string
+		expectedErrStr string
+	}
+	cases := []testCase{
+		{"a\\x4?", []string{"a", "@ABCDEFGHIJKLMNO"}, ""},
+		{"\\x?1", []string{"\x01\x11\x21\x31\x41\x51\x61\x71\x81\x91\xa1\xb1\xc1\xd1\xe1\xf1"}, ""},
+		{"[\\x30-\\x39]x", []string{"0123456789", "x"}, ""},
+		{"[a-cx\\x00\\]-]", []string{"\x00-]abcx"}, ""},
+		{"[0-2][^\\x01-\\xff]", []string{"012", "\x00"}, ""},
+		{"{40/F1}", []string{"@BDFHJLN"}, ""},
+		{"{01/01}{00/FF}", []string{string(setBytes(maskedByte(1, 1))), "\x00"}, ""},
+		{"\\[\\]\\{\\}\\-\\^", []string{"[", "]", "{", "}", "-", "^"}, ""},
+		{"[]", nil, "Empty byte class: '[]'"},
+		{"[abc", nil, "Missing closing ']' for byte class"},
+		{"[\\x3?]", nil, "Wildcards not allowed in byte class: '\\x3?'"},
+		{"[z-a]", nil, "Invalid byte class range: 0x7a-0x61"},
+		{"{4/F0}", nil, "Expected value/mask of form: {VV/MM}, ex: {40/F0}"},
+		{"{4G/F0}", nil, "Invalid value in value/mask: \"{4G/F0}\""},
+		{"{40/FZ}", nil, "Invalid mask in value/mask: \"{40/FZ}\""},
+		{"\\x4G", nil, "'Invalid hex sequence: '\\x4G'"},
+		{"\\x??", nil, "pattern cannot be all '?' (match any byte)"},
+		{"[^\\x00-\\xff]", nil, ""}, // matches nothing, but still valid
+	}
+	for _, c := range cases {
+		pattern, err := parseSearchPattern(c.inputPattern)
+		if err == nil && c.expectedErrStr != "" {
+			t.Errorf("input: %q, err nil, but expected: %q", c.inputPattern, c.expectedErrStr)
+		}
+		if err != nil && err.Error() != c.expectedErrStr {
+			t.Errorf("input: %q, unexpected err, expected: %q, got: %q", c.inputPattern, c.expectedErrStr, err.Error())
+		}
+		if err != nil || c.expected == nil {
+			continue
+		}
+		if len(pattern) != len(c.expected) {
+			t.Errorf("input: %q, expected pattern len: %d, got: %d", c.inputPattern, len(c.expected), len(pattern))
+			continue
+		}
+		for i, set := range pattern {
+			if got := string(setBytes(set)); got != c.expected[i] {
+				t.Errorf("input: %q, position: %d, expected to match: %q, got: %q", c.inputPattern, i, c.expected[i], got)
+			}
+		}
+	}
+}
+
+func Test_searcher_update_byteSets(t *testing.T) {
+	// opcode like search: 0x4? prefix, then mov with fixed high bits then a digit.
+	s, err := NewSearcher("\\x4?{B8/F8}[0-9]", 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected err creating searcher: %s", err)
+	}
+	s.update([]byte("\x48\xb8\x31\x41\xbf9\x50\xb81\x48\xc01\x4f\xbaz"))
+	if starts := matchStarts(s.matches); len(starts) != 2 || starts[0] != 0 || starts[1] != 3 {
+		t.Errorf("Unexpected match starts, expected: [0 3], got: %v", starts)
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+const (
+	defaultRegexWindow = 4096
+)
+
+// NewRegexSearcher creates a searcher that finds matches of Go (RE2 syntax)
+// regular expressions. The expressions are matched against raw bytes, so use
+// "\xAB" style escapes for non-ascii values and "(?s)" if "." should also
+// match newlines.
+//
+// Since input is streamed, a match must fit within window bytes. Data is
+// searched as it arrives, but any match that starts within window bytes of the
+// end of the data seen so far is held back until either more data arrives or
+// the input ends. This keeps matches that straddle chunk boundaries intact.
+// Matches longer than window may be split up or cut short.
+func NewRegexSearcher(exprs []string, window int, showBeforeBytes int, showAfterBytes int) (*searcher, error) {
+	if len(exprs) == 0 {
+		return nil, errors.New("empty pattern")
+	}
+	if window < 1 {
+		return nil, fmt.Errorf("regex window must be > 0, got: %d", window)
+	}
+	s := searcher{
+		labels:          exprs,
+		maxPatternLen:   window,
+		showBeforeBytes: showBeforeBytes,
+		showAfterBytes:  showAfterBytes,
+		lastMatchEnds:   make([]int, len(exprs)),
+		regexResumeAt:   make([]int, len(exprs)),
+	}
+	for i, expr := range exprs {
+		if len(expr) == 0 {
+			return nil, errors.New("empty pattern")
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid regex %q: %v", expr, err)
+		}
+		s.regexes = append(s.regexes, re)
+		// Go's regexp can't start partway through the data, so this matches
+		// the byte before where the search resumes followed by the expression
+		// to give "^", "\b" etc the same context as searching all of the data.
+		resumeRe, err := regexp.Compile("(?s:.)(" + expr + ")")
+		if err != nil {
+			return nil, fmt.Errorf("Invalid regex %q: %v", expr, err)
+		}
+		s.resumeRegexes = append(s.resumeRegexes, resumeRe)
+		s.lastMatchEnds[i] = -1
+	}
+	return &s, nil
+}
+
+// updateRegexes searches the data not yet searched by each regex, which is
+// the tail end of s.history followed by inChunk.
+// Matches that start within s.maxPatternLen (the window) of the end of
+// available data could still change with more data (ex: "a+" at the end of a chunk)
+// so those are left to be searched again on the next call unless atEOF.
+func (s *searcher) updateRegexes(inChunk []byte, atEOF bool) {
+	window := s.maxPatternLen
+	dataEnd := s.bytesConsumed + len(inChunk) // absolute, exclusive
+
+	searchFrom := s.regexResumeAt[0]
+	for _, resumeAt := range s.regexResumeAt {
+		if resumeAt < searchFrom {
+			searchFrom = resumeAt
+		}
+	}
+	// include the byte before, see resumeRegexes
+	dataStart := searchFrom
+	if historyStart := s.bytesConsumed - len(s.history); dataStart > historyStart {
+		dataStart--
+	}
+	data := s.lookBack(inChunk, dataStart, dataEnd)
+
+	// Matches can only be decided when they start at or before this point
+	// as only then any match (being at most window long) is fully within data.
+	decidedEnd := dataEnd - window
+	if atEOF {
+		decidedEnd = dataEnd
+	}
+	firstNew := len(s.matches)
+	for i := range s.regexes {
+		for pos := s.regexResumeAt[i]; pos <= dataEnd; {
+			var start, end int // absolute, end is exclusive
+			if pos == dataStart {
+				// at the start of the input, nothing before it
+				loc := s.regexes[i].FindIndex(data)
+				if loc == nil {
+					break
+				}
+				start, end = dataStart+loc[0], dataStart+loc[1]
+			} else {
+				loc := s.resumeRegexes[i].FindSubmatchIndex(data[pos-1-dataStart:])
+				if loc == nil {
+					break
+				}
+				start, end = pos-1+loc[2], pos-1+loc[3]
+			}
+			if start > decidedEnd {
+				break
+			}
+			if end == start {
+				// zero length match, nothing to show
+				pos = end + 1
+				continue
+			}
+			s.addMatch(inChunk, start, end-1, i)
+			s.regexResumeAt[i] = end
+			pos = end
+		}
+		if decidedEnd+1 > s.regexResumeAt[i] {
+			s.regexResumeAt[i] = decidedEnd + 1
+		}
+	}
+
+	// Keep matches in order of where they end like the other matchers.
+	newMatches := s.matches[firstNew:]
+	sort.SliceStable(newMatches, func(a, b int) bool {
+		if newMatches[a].endIndex != newMatches[b].endIndex {
+			return newMatches[a].endIndex < newMatches[b].endIndex
+		}
+		return newMatches[a].patternIndex < newMatches[b].patternIndex
+	})
+}
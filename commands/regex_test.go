@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_NewRegexSearcher(t *testing.T) {
+	if _, err := NewRegexSearcher([]string{"a(b"}, 10, 0, 0); err == nil ||
+		!strings.HasPrefix(err.Error(), "Invalid regex \"a(b\": ") {
+		t.Errorf("Expected invalid regex error, got: %v", err)
+	}
+	if _, err := NewRegexSearcher([]string{"ab"}, 0, 0, 0); err == nil ||
+		err.Error() != "regex window must be > 0, got: 0" {
+		t.Errorf("Expected invalid window error, got: %v", err)
+	}
+	if _, err := NewRegexSearcher([]string{}, 10, 0, 0); err == nil || err.Error() != "empty pattern" {
+		t.Errorf("Expected empty pattern error, got: %v", err)
+	}
+}
+
+func Test_searcher_updateRegexes(t *testing.T) {
+	s, err := NewRegexSearcher([]string{"PK\\x03\\x04[ -~]{4,30}", "z+", "q*"}, 64, 2, 2)
+	if err != nil {
+		t.Fatalf("Unexpected err creating searcher: %s", err)
+	}
+	s.update([]byte("..PK\x03\x04file"))
+	// nothing decided yet as all of the data is within the window
+	if len(s.matches) != 0 {
+		t.Fatalf("Expected no matches yet, got: %d", len(s.matches))
+	}
+	s.update([]byte("name.txt\x00zzz"))
+	s.finish()
+	type hit struct {
+		value        string
+		patternIndex int
+	}
+	// NOTE: zero length matches of q* are omitted
+	expected := []hit{{"PK\x03\x04filename.txt", 0}, {"zzz", 1}}
+	got := make([]hit, 0)
+	for _, m := range s.matches {
+		got = append(got, hit{string(m.matchedValue), m.patternIndex})
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected matches, expected: %q, got: %q", expected, got)
+	}
+	if !reflect.DeepEqual(s.matches[0].beforeBytes, []byte("..")) {
+		t.Errorf("Unexpected before value, expect: %q, got: %q", "..", s.matches[0].beforeBytes)
+	}
+	if !reflect.DeepEqual(s.matches[0].afterBytes, []byte("\x00z")) {
+		t.Errorf("Unexpected after value, expect: %q, got: %q", "\x00z", s.matches[0].afterBytes)
+	}
+}
+
+// Tests that matches straddling chunk boundaries are found intact no matter how input is chunked.
+func Test_searcher_updateRegexes_anyChunkSize(t *testing.T) {
+	data := []byte(strings.Repeat("..PK\x03\x04hello.txt\x00aaaa\x00bb\x01PK\x03\x04x\x00", 5))
+	exprs := []string{"PK\\x03\\x04[ -~]{4,30}", "a+", "b\\x01?P"}
+	whole, _ := NewRegexSearcher(exprs, 32, 3, 3)
+	whole.update(data)
+	whole.finish()
+	if len(whole.matches) != 15 {
+		t.Fatalf("Expected %d matches, got: %d", 15, len(whole.matches))
+	}
+	for chunkSize := 1; chunkSize < 40; chunkSize++ {
+		chunked, _ := NewRegexSearcher(exprs, 32, 3, 3)
+		for i := 0; i < len(data); i += chunkSize {
+			end := i + chunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+			chunked.update(data[i:end])
+		}
+		chunked.finish()
+		if !reflect.DeepEqual(chunked.matches, whole.matches) {
+			t.Errorf("chunk size: %d, expected matches: %v, got: %v",
+				chunkSize, matchStarts(whole.matches), matchStarts(chunked.matches))
+		}
+	}
+}
+
+// Tests that anchors and word boundaries see the data before where a search
+// resumes, rather than treating it as the start of the input.
+func Test_searcher_updateRegexes_anchorsAcrossChunks(t *testing.T) {
+	data := []byte("head AB cat concat\nABxAB catx\n\nAB cat")
+	exprs := []string{"^AB", "(?m)^AB", "\\bcat\\b", "\\Bcat", "\\Ahead", "(?m)$\\n"}
+	for i, expr := range exprs {
+		var expected []int
+		for _, loc := range regexp.MustCompile(expr).FindAllIndex(data, -1) {
+			expected = append(expected, loc[0])
+		}
+		for chunkSize := 1; chunkSize <= len(data); chunkSize++ {
+			s, _ := NewRegexSearcher([]string{expr}, 8, 0, 0)
+			for start := 0; start < len(data); start += chunkSize {
+				end := start + chunkSize
+				if end > len(data) {
+					end = len(data)
+				}
+				s.update(data[start:end])
+			}
+			s.finish()
+			if starts := matchStarts(s.matches); !reflect.DeepEqual(starts, expected) && !(len(starts) == 0 && len(expected) == 0) {
+				t.Errorf("expr %d: %q, chunk size: %d, expected matches at: %v, got: %v", i, expr, chunkSize, expected, starts)
+			}
+		}
+	}
+}
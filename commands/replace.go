@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/jcuga/hax/options"
+)
+
+const replaceUsage = `Usage: replace [options] <pattern> <replacement>
+Outputs input with matches of pattern replaced, in any output mode.
+options:
+  -m, --max-count n	only replace the first n matches (default 0, no max)
+  -i, --ignore-case	ascii letters match either case
+  --regex	pattern is a Go/RE2 regular expression matched against bytes
+  --window n	max length of a regex match in bytes (default 4096)
+Options can also come after pattern and replacement. Use -- before either if it starts with '-'.
+pattern has the same syntax as search, see: hax search --help
+replacement is exact bytes, using escapes like \xAB or a typed number like u32le:0x1234
+(escape the ':' as \x3A for text like that), and can be empty to delete matches,
+ex: replace 'C:\\Users' 'D:\\Data' or replace \x00\x00 ''`
+
+// replaceReader streams the wrapped reader with all matches of a searcher
+// swapped for a replacement. Overlapping matches are resolved the same way
+// as search: the first (leftmost) match wins.
+//
+// Data that could still be the start of a match is held back until enough
+// has been read to know, so at most the searcher's maxPatternLen bytes are
+// buffered beyond what is decided.
+type replaceReader struct {
+	source      io.Reader
+	remaining   int64 // source bytes left to read before hitting limit
+	s           *searcher
+	replacement []byte
+	maxCount    int
+	numReplaced int
+	readBuf     []byte
+	// source bytes not yet output or skipped, starting at absolute index pendingStart
+	pending      []byte
+	pendingStart int
+	// matches that could still be preceded by an earlier starting match
+	candidates []searchMatch
+	// ready to be read
+	out  []byte
+	done bool
+}
+
+// NewReplaceReader parses the replace command's options and returns a reader
+// of up to opts.Limit bytes of reader with matches replaced.
+func NewReplaceReader(reader io.Reader, opts options.Options, cmdOptions []string) (io.Reader, error) {
+	flags := flag.NewFlagSet("replace", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard) // we report errors along with our own usage text
+	maxCount := flags.Int("max-count", 0, "")
+	flags.IntVar(maxCount, "m", 0, "")
+	ignoreCase := flags.Bool("ignore-case", false, "")
+	flags.BoolVar(ignoreCase, "i", false, "")
+	useRegex := flags.Bool("regex", false, "")
+	regexWindow := flags.Int("window", defaultRegexWindow, "")
+	args, err := parseInterspersed(flags, cmdOptions)
+	if err != nil {
+		return nil, fmt.Errorf("%v\n%s", err, replaceUsage)
+	}
+	if len(args) != 2 {
+		return nil, errors.New(replaceUsage)
+	}
+	if *maxCount < 0 {
+		return nil, fmt.Errorf("--max-count must be >= 0, got: %d", *maxCount)
+	}
+	pattern := args[0]
+	replacement, err := parseReplacement(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid replacement %q: %v", args[1], err)
+	}
+
+	var s *searcher
+	if *useRegex {
+		if *ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		s, err = NewRegexSearcher([]string{pattern}, *regexWindow, 0, 0)
+	} else {
+		s, err = newTextSearcher([]string{pattern}, textOptions{ignoreCase: *ignoreCase}, 0, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// overlaps are resolved by replaceReader instead
+	s.overlapping = true
+	return newReplaceReader(reader, opts.Limit, s, replacement, *maxCount), nil
+}
+
+func newReplaceReader(reader io.Reader, limit int64, s *searcher, replacement []byte, maxCount int) *replaceReader {
+	return &replaceReader{
+		source:      reader,
+		remaining:   limit,
+		s:           s,
+		replacement: replacement,
+		maxCount:    maxCount,
+		readBuf:     make([]byte, options.OutputBufferSize),
+	}
+}
+
+// parseReplacement parses the exact bytes to replace matches with.
+// Uses the same escapes as search patterns, but without any wildcards.
+func parseReplacement(input string) ([]byte, error) {
+	encodings, isTyped, err := parseTypedNumber(input)
+	if err != nil {
+		return nil, err
+	}
+	if isTyped {
+		if len(encodings) > 1 {
+			return nil, errors.New("typed number must specify le or be")
+		}
+		return encodings[0].value, nil
+	}
+	replacement := make([]byte, 0, len(input))
+	for i := 0; i < len(input); {
+		set, consumed, err := parsePatternElement(input[i:])
+		if err != nil {
+			return nil, err
+		}
+		val, ok := set.literal()
+		if !ok {
+			return nil, fmt.Errorf("Wildcards not allowed in replacement: '%s'", input[i:i+consumed])
+		}
+		replacement = append(replacement, val)
+		i += consumed
+	}
+	return replacement, nil
+}
+
+func (r *replaceReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 && !r.done {
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+	}
+	if len(r.out) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// fill reads the next chunk of source data and outputs whatever is decided.
+func (r *replaceReader) fill() error {
+	n := 0
+	if r.remaining > 0 {
+		toRead := r.readBuf
+		if r.remaining < int64(len(toRead)) {
+			toRead = toRead[:r.remaining]
+		}
+		var err error
+		n, err = r.source.Read(toRead)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+	}
+
+	// Any match yet to be found ends at or after the data read so far, so
+	// can't start any earlier than this. When out of data, all is decided.
+	decidedEnd := r.s.bytesConsumed + n - (r.s.maxPatternLen - 1)
+	if n > 0 {
+		r.s.update(r.readBuf[:n])
+		r.pending = append(r.pending, r.readBuf[:n]...)
+		r.remaining -= int64(n)
+	} else {
+		r.s.finish()
+		r.done = true
+		decidedEnd = r.s.bytesConsumed
+	}
+	r.candidates = append(r.candidates, r.s.matches...)
+	r.s.matches = r.s.matches[:0]
+
+	// matches are found in order of where they end, but need to be replaced
+	// in order of where they start.
+	sort.SliceStable(r.candidates, func(a, b int) bool {
+		return r.candidates[a].startIndex < r.candidates[b].startIndex
+	})
+	numDecided := 0
+	for numDecided < len(r.candidates) && r.candidates[numDecided].startIndex < decidedEnd {
+		m := r.candidates[numDecided]
+		numDecided++
+		if m.startIndex < r.pendingStart || (r.maxCount > 0 && r.numReplaced >= r.maxCount) {
+			// overlaps an already replaced match or hit max
+			continue
+		}
+		r.advance(m.startIndex, true)
+		r.out = append(r.out, r.replacement...)
+		r.advance(m.endIndex+1, false)
+		r.numReplaced++
+	}
+	r.candidates = r.candidates[numDecided:]
+	r.advance(decidedEnd, true)
+	return nil
+}
+
+// advance moves past pending data up to absolute index end, outputting it
+// if keep, otherwise it is dropped (replaced).
+func (r *replaceReader) advance(end int, keep bool) {
+	if end <= r.pendingStart {
+		return
+	}
+	num := end - r.pendingStart
+	if keep {
+		r.out = append(r.out, r.pending[:num]...)
+	}
+	r.pending = r.pending[num:]
+	r.pendingStart = end
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/jcuga/hax/options"
+)
+
+func Test_NewReplaceReader(t *testing.T) {
+	type testCase struct {
+		input          string
+		cmdOptions     []string
+		limit          int64
+		expected       string
+		expectedErrStr string
+	}
+	cases := []testCase{
+		{"hello world, hello hax", []string{"hello", "bye"}, 0, "bye world, bye hax", ""},
+		{"hello world, hello hax", []string{"hello", "howdy!"}, 0, "howdy! world, howdy! hax", ""},
+		{"a\x00b\x00c", []string{"\\x00", ""}, 0, "abc", ""},
+		// first match wins:
+		{"aaaaa", []string{"aa", "X"}, 0, "XXa", ""},
+		{"aaaaa", []string{"-m", "1", "aa", "X"}, 0, "Xaaa", ""},
+		{"Hello HELLO", []string{"-i", "hello", "hi"}, 0, "hi hi", ""},
+		// options after the pattern and replacement:
+		{"Hello HELLO", []string{"hello", "hi", "-i"}, 0, "hi hi", ""},
+		{"aaaaa", []string{"aa", "-m", "1", "X"}, 0, "Xaaa", ""},
+		{"a-b", []string{"-i", "--", "-", "-x"}, 0, "a-xb", ""},
+		{"ab\x01\x00\x00\x00cd", []string{"u32le:1", "u16be:0xFFFE"}, 0, "ab\xFF\xFEcd", ""},
+		{"a1b?c", []string{"[0-9?]", "\\x2D"}, 0, "a-b-c", ""},
+		{"u8:1\x01", []string{"u8\\x3A1", "u16be\\x3A2"}, 0, "u16be:2\x01", ""},
+		{"x12y345z", []string{"--regex", "[0-9]+", "#"}, 0, "x#y#z", ""},
+		{"ABAB\nAB cat concat", []string{"--regex", "(?m)^AB|\\bcat", "_"}, 0, "_AB\n_ _ concat", ""},
+		// limit applies to the data being replaced:
+		{"abcabc", []string{"c", "Z"}, 4, "abZa", ""},
+		{"abcabc", []string{"bc", "Z"}, 2, "ab", ""},
+		// errors:
+		{"abc", []string{"a"}, 0, "", replaceUsage},
+		{"abc", []string{"a", "?"}, 0, "", "Invalid replacement \"?\": Wildcards not allowed in replacement: '?'"},
+		{"abc", []string{"a", "u16:1"}, 0, "", "Invalid replacement \"u16:1\": typed number must specify le or be"},
+		{"abc", []string{"-m", "-2", "a", "b"}, 0, "", "--max-count must be >= 0, got: -2"},
+	}
+	for _, c := range cases {
+		limit := c.limit
+		if limit == 0 {
+			limit = math.MaxInt64
+		}
+		// one byte at a time to make sure matches across reads are replaced.
+		reader, err := NewReplaceReader(iotest.OneByteReader(strings.NewReader(c.input)), options.Options{Limit: limit}, c.cmdOptions)
+		if err == nil && c.expectedErrStr != "" {
+			t.Errorf("options: %q, err nil, but expected: %q", c.cmdOptions, c.expectedErrStr)
+		}
+		if err != nil {
+			if err.Error() != c.expectedErrStr {
+				t.Errorf("options: %q, unexpected err, expected: %q, got: %q", c.cmdOptions, c.expectedErrStr, err.Error())
+			}
+			continue
+		}
+		result, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Errorf("options: %q, unexpected read err: %v", c.cmdOptions, err)
+		}
+		if string(result) != c.expected {
+			t.Errorf("options: %q, unexpected output, expected: %q, got: %q", c.cmdOptions, c.expected, string(result))
+		}
+	}
+}
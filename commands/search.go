@@ -2,8 +2,12 @@ package commands
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -11,34 +15,389 @@ import (
 	"github.com/jcuga/hax/options"
 )
 
-const (
-	anyByte = 256
-)
+// ErrNoMatches is returned by Search when nothing matched, so callers can
+// tell that apart from other errors like grep's exit status does.
+var ErrNoMatches = errors.New("No matches found")
+
+const searchUsage = `Usage: search [options] <pattern>...
+options:
+  --context b:a	num bytes before/after to display on matches (default 8:8)
+  --pattern-file <file>	search for patterns listed in file, one per line. Empty and '#' lines are ignored
+  --overlap	report matches that overlap previous ones of same pattern (default: first match wins)
+  --regex	patterns are Go/RE2 regular expressions matched against bytes, ex: search --regex 'PK\x03\x04[ -~]{4,30}'
+  --window n	max length of a regex match in bytes (default 4096). Longer matches may be split up
+  -i, --ignore-case	ascii letters match either case
+  --encoding <enc>	search for pattern text encoded as utf16le, utf16be, utf32le or utf32be.
+    utf16 and utf32 search for both le and be. Non-ascii text is taken as UTF-8, ex:
+    search --encoding utf16le 'C:\\Windows' matches 43 00 3A 00 5C 00 57 00 ...
+  -c, --count	only print the number of matches
+  --offsets	only print the offset of each match, one per line, in hex (ex: 0x2B)
+  --decimal	print --offsets in decimal instead of hex
+  -m, --max-count n	stop after n matches (default 0, no max)
+  --first	stop after the first match, same as --max-count 1
+  -q, --quiet	print nothing, only set the exit status
+Exit status is 0 if anything matched, 1 if nothing matched, and 2 on error.
+With multiple --range, offsets are in the input and matches spanning ranges that aren't next to
+each other in the input are skipped.
+Options can also come after patterns. Use -- before any pattern starting with '-', ex: search -- -x
+With a single pattern, b:a can also be given as 2nd argument, ex: search \x01\x02\x03 5:7
+pattern syntax (when not --regex):
+  literal bytes and escapes: \xAB \n \r \t \\
+  ? matches any byte, \x4? and \x?F match any high/low nibble
+  [\x30-\x39] [abc] [^\x00] match any byte in/not in the class
+  {40/F0} matches bytes where (byte & mask) == value
+  use \? \[ \] \{ \} to match those chars literally
+  typed numbers: <type>[le|be]:<value> where type is u8-u64, i8-i64, f32 or f64,
+    ex: u32le:0xDEADBEEF i16be:-2 f32:3.14 u16:0x40*2. No le/be searches for both.
+    To search for text like that instead, escape the ':' as \x3A, ex: u8\x3A12`
 
 func Search(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options,
 	cmdOptions []string) error {
-	if len(cmdOptions) < 1 || len(cmdOptions) > 2 {
-		return errors.New("Usage: search <pattern> [b:a]\noptional b:a is num bytes before/after to display on matches, ex: search \\x01\\x02\\x03 5:7")
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard) // we report errors along with our own usage text
+	overlapping := flags.Bool("overlap", false, "")
+	context := flags.String("context", "", "")
+	patternFile := flags.String("pattern-file", "", "")
+	useRegex := flags.Bool("regex", false, "")
+	regexWindow := flags.Int("window", defaultRegexWindow, "")
+	ignoreCase := flags.Bool("ignore-case", false, "")
+	flags.BoolVar(ignoreCase, "i", false, "")
+	encoding := flags.String("encoding", "", "")
+	countOnly := flags.Bool("count", false, "")
+	flags.BoolVar(countOnly, "c", false, "")
+	offsetsOnly := flags.Bool("offsets", false, "")
+	decimalOffsets := flags.Bool("decimal", false, "")
+	maxMatches := flags.Int("max-count", 0, "")
+	flags.IntVar(maxMatches, "m", 0, "")
+	firstOnly := flags.Bool("first", false, "")
+	quiet := flags.Bool("quiet", false, "")
+	flags.BoolVar(quiet, "q", false, "")
+	patterns, err := parseInterspersed(flags, cmdOptions)
+	if err != nil {
+		return fmt.Errorf("%v\n%s", err, searchUsage)
+	}
+	// backwards compatible: "search <pattern> b:a"
+	if len(patterns) == 2 && *context == "" && *patternFile == "" {
+		if _, _, err := parseBeforeAfter(patterns[1]); err == nil {
+			*context = patterns[1]
+			patterns = patterns[:1]
+		}
+	}
+	if len(*patternFile) > 0 {
+		filePatterns, err := readPatternFile(*patternFile)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+	if len(patterns) < 1 {
+		return errors.New(searchUsage)
+	}
+	numModes := 0
+	for _, mode := range []bool{*countOnly, *offsetsOnly, *quiet} {
+		if mode {
+			numModes++
+		}
+	}
+	if numModes > 1 {
+		return errors.New("Only one of --count, --offsets or --quiet can be used at a time")
+	}
+	if *decimalOffsets && !*offsetsOnly {
+		return errors.New("--decimal requires --offsets")
+	}
+	if *maxMatches < 0 {
+		return fmt.Errorf("--max-count must be >= 0, got: %d", *maxMatches)
 	}
 	beforeBytes, afterBytes := 8, 8
-	var err error
-	if len(cmdOptions) == 2 {
-		beforeBytes, afterBytes, err = parseBeforeAfter(cmdOptions[1])
+	if numModes > 0 {
+		// not displaying matches, so no need for context
+		beforeBytes, afterBytes = 0, 0
+	} else if len(*context) > 0 {
+		beforeBytes, afterBytes, err = parseBeforeAfter(*context)
 		if err != nil {
 			return err
 		}
 	}
 
-	fmt.Printf("pattern raw: %q\n", cmdOptions[0])
-
-	s, err := NewSearcher(cmdOptions[0], beforeBytes, afterBytes)
+	var s *searcher
+	if *useRegex {
+		if *overlapping {
+			return errors.New("--overlap is not supported with --regex")
+		}
+		if len(*encoding) > 0 {
+			return errors.New("--encoding is not supported with --regex")
+		}
+		if *ignoreCase {
+			for i := range patterns {
+				patterns[i] = "(?i)" + patterns[i]
+			}
+		}
+		s, err = NewRegexSearcher(patterns, *regexWindow, beforeBytes, afterBytes)
+	} else {
+		var encodings []textEncoding
+		encodings, err = parseTextEncodings(*encoding)
+		if err != nil {
+			return err
+		}
+		s, err = newTextSearcher(patterns, textOptions{ignoreCase: *ignoreCase, encodings: encodings}, beforeBytes, afterBytes)
+	}
 	if err != nil {
 		return err
 	}
-	if s != nil { // TODO: remove me
+	s.overlapping = *overlapping
 
+	maxCount := *maxMatches
+	if *firstOnly || *quiet {
+		maxCount = 1
+	}
+	numFound := 0
+	// report matches in the requested style, up to maxCount of them
+	report := func(matches []searchMatch) {
+		for _, m := range matches {
+			if maxCount > 0 && numFound >= maxCount {
+				return
+			}
+			offset := opts.Offset + int64(m.startIndex)
+			if len(opts.Ranges) > 1 {
+				var ok bool
+				if m, offset, ok = mapToRanges(m, opts.Ranges); !ok {
+					continue
+				}
+			}
+			numFound++
+			switch {
+			case *offsetsOnly && *decimalOffsets:
+				fmt.Fprintf(writer, "%d\n", offset)
+			case *offsetsOnly:
+				fmt.Fprintf(writer, "0x%X\n", offset)
+			case *countOnly || *quiet:
+				// only reported at the end, if at all
+			default:
+				printSearchMatch(writer, m, offset, numFound, s.label(m), ioInfo, opts)
+			}
+		}
+	}
+
+	buf := make([]byte, options.OutputBufferSize)
+	bytesRead := int64(0)
+
+	for {
+		var n int
+		var err error
+		// only read up to limit many bytes:
+		if opts.Limit-bytesRead < options.OutputBufferSize {
+			n, err = reader.Read(buf[:opts.Limit-bytesRead])
+		} else {
+			n, err = reader.Read(buf)
+		}
+
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+
+		s.update(buf[:n])
+		// Only print matches once all of their after-context has arrived.
+		// Any still waiting on more data are printed on subsequent iterations
+		// or once we run out of input.
+		numComplete := 0
+		for numComplete < len(s.matches) && len(s.matches[numComplete].afterBytes) == s.showAfterBytes {
+			numComplete++
+		}
+		report(s.matches[:numComplete])
+		s.matches = s.matches[numComplete:]
+
+		bytesRead += int64(n)
+		if bytesRead >= opts.Limit || (maxCount > 0 && numFound >= maxCount) {
+			break
+		}
+	}
+
+	// no more data, print any matches still waiting on after-context
+	s.finish()
+	report(s.matches)
+	s.matches = s.matches[:0]
+
+	if *countOnly {
+		fmt.Fprintf(writer, "%d\n", numFound)
+	}
+	if numFound == 0 {
+		return ErrNoMatches
+	}
+	return nil
+}
+
+// mapToRanges gives the offset in the input of a match in data made of
+// multiple ranges one after another. A match spanning ranges that aren't next
+// to each other in the input isn't really in the input so ok is false.
+// The match's context is trimmed to the ranges it's in so that it lines up
+// with the input offsets.
+func mapToRanges(m searchMatch, ranges []options.Range) (mapped searchMatch, offset int64, ok bool) {
+	// index of the range containing pos, and where that range starts in the data
+	findRange := func(pos int64) (int, int64) {
+		start := int64(0)
+		for i, r := range ranges {
+			if pos < start+r.Limit || i == len(ranges)-1 {
+				return i, start
+			}
+			start += r.Limit
+		}
+		return len(ranges) - 1, start
+	}
+	first, firstStart := findRange(int64(m.startIndex))
+	last, lastStart := findRange(int64(m.endIndex))
+	prev := ranges[first]
+	for _, r := range ranges[first+1 : last+1] {
+		if r.Limit == 0 {
+			continue
+		}
+		if r.Offset != prev.Offset+prev.Limit {
+			return m, 0, false
+		}
+		prev = r
+	}
+
+	if before := int64(m.startIndex) - firstStart; before < int64(len(m.beforeBytes)) {
+		m.beforeBytes = m.beforeBytes[int64(len(m.beforeBytes))-before:]
+	}
+	if after := lastStart + ranges[last].Limit - int64(m.endIndex) - 1; after < int64(len(m.afterBytes)) {
+		m.afterBytes = m.afterBytes[:after]
+	}
+	return m, ranges[first].Offset + int64(m.startIndex) - firstStart, true
+}
+
+// printSearchMatch displays a match found at offset in the input along with
+// its before/after context as rows of hex and ascii, laid out the same way as
+// the hex editor display output.
+// Rows are aligned to the display width so offsets line up across matches.
+// A non-empty label identifies which of multiple search patterns matched.
+func printSearchMatch(writer io.Writer, m searchMatch, offset int64, matchNum int, label string, ioInfo options.IOInfo, opts options.Options) {
+	subWidthPadding := "  " // same as display output
+	width := opts.Display.Width
+	if width < 1 {
+		// a width of 0 means no wrapping for other output modes, but we always want rows here.
+		width = 16
+	}
+	matchStart := offset
+	matchEnd := offset + int64(m.endIndex-m.startIndex)
+	dataStart := matchStart - int64(len(m.beforeBytes))
+	data := make([]byte, 0, len(m.beforeBytes)+len(m.matchedValue)+len(m.afterBytes))
+	data = append(data, m.beforeBytes...)
+	data = append(data, m.matchedValue...)
+	data = append(data, m.afterBytes...)
+	dataEnd := dataStart + int64(len(data)) // exclusive
+
+	if matchNum > 1 {
+		fmt.Fprintf(writer, "\n")
+	}
+	header := fmt.Sprintf("match %d at %X-%X (%d bytes)", matchNum, matchStart, matchEnd, len(m.matchedValue))
+	if len(label) > 0 {
+		header += fmt.Sprintf(" pattern %d: %s", m.patternIndex+1, label)
+	}
+	if ioInfo.OutputPretty {
+		fmt.Fprintf(writer, "\033[1m%s:\033[0m\n", header)
+	} else {
+		fmt.Fprintf(writer, "%s:\n", header)
+	}
+
+	for rowStart := dataStart - (dataStart % int64(width)); rowStart < dataEnd; rowStart += int64(width) {
+		if ioInfo.OutputPretty {
+			fmt.Fprintf(writer, "\033[36m%13X: \033[0m", rowStart)
+		} else {
+			fmt.Fprintf(writer, "%13X: ", rowStart)
+		}
+		// Print hex
+		for i := 0; i < width; i++ {
+			pos := rowStart + int64(i)
+			if pos >= dataEnd {
+				break
+			}
+			if opts.Display.SubWidth > 0 && i > 0 && i%opts.Display.SubWidth == 0 {
+				fmt.Fprintf(writer, "%s", subWidthPadding)
+			}
+			if pos < dataStart {
+				// pad so first row is aligned with the column of its offset
+				fmt.Fprintf(writer, "   ")
+			} else if ioInfo.OutputPretty && pos >= matchStart && pos <= matchEnd {
+				fmt.Fprintf(writer, "\033[1;31m%02X\033[0m ", data[pos-dataStart])
+			} else {
+				fmt.Fprintf(writer, "%02X ", data[pos-dataStart])
+			}
+		}
+		fmt.Fprintf(writer, "\n")
+		if opts.Display.Quiet {
+			continue
+		}
+		// Print ascii
+		fmt.Fprintf(writer, "%15s", "")
+		for i := 0; i < width; i++ {
+			pos := rowStart + int64(i)
+			if pos >= dataEnd {
+				break
+			}
+			if opts.Display.SubWidth > 0 && i > 0 && i%opts.Display.SubWidth == 0 {
+				fmt.Fprintf(writer, "%s", subWidthPadding)
+			}
+			if pos < dataStart {
+				fmt.Fprintf(writer, "   ")
+				continue
+			}
+			out := asciiDisplayValue(data[pos-dataStart])
+			if ioInfo.OutputPretty {
+				if pos >= matchStart && pos <= matchEnd {
+					fmt.Fprintf(writer, "%2s ", fmt.Sprintf("\033[1;31m%2s\033[0m", out))
+				} else {
+					fmt.Fprintf(writer, "%2s ", fmt.Sprintf("\033[32m%2s\033[0m", out))
+				}
+			} else {
+				fmt.Fprintf(writer, "%2s ", out)
+			}
+		}
+		fmt.Fprintf(writer, "\n")
+	}
+}
+
+// asciiDisplayValue gives the 2 char wide representation of a byte used in
+// the ascii rows of hex display output: printable ascii, common escapes, or blank.
+func asciiDisplayValue(b byte) string {
+	if b >= 32 && b <= 126 {
+		return fmt.Sprintf("%2c", b)
+	}
+	switch b {
+	case 0x09:
+		return fmt.Sprintf("%2s", "\\t")
+	case 0x0A:
+		return fmt.Sprintf("%2s", "\\n")
+	case 0x0D:
+		return fmt.Sprintf("%2s", "\\r")
+	default:
+		// just padding
+		return fmt.Sprintf("%2s", "")
+	}
+}
+
+// parseInterspersed parses flags that can come before, between or after the
+// non-flag args, ex: "search DEADBEEF -c", and returns the non-flag args.
+// Anything after "--" is a non-flag arg, ex: "search -- -x".
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	nonFlags := make([]string, 0)
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		parsed := len(args) - flags.NArg()
+		if parsed > 0 && args[parsed-1] == "--" {
+			return append(nonFlags, flags.Args()...), nil
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return nonFlags, nil
+		}
+		nonFlags = append(nonFlags, args[0])
+		args = args[1:]
 	}
-	return nil // TODO: build me
 }
 
 // parseBeforeAfter takes a string of form "<int>:<int>" where the ints
@@ -62,75 +421,133 @@ func parseBeforeAfter(input string) (int, int, error) {
 	return before, after, nil
 }
 
+// readPatternFile gets search patterns from a file, one per line.
+// Lines that are empty or start with '#' are skipped. Use "\x23" to search for a leading '#'.
+func readPatternFile(filename string) ([]string, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read pattern file: %v", err)
+	}
+	patterns := make([]string, 0)
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("No patterns in pattern file: %q", filename)
+	}
+	return patterns, nil
+}
+
 // NewSearcher creates a searcher with a given byte pattern parsed from inputPattern.
 func NewSearcher(inputPattern string, showBeforeBytes int, showAfterBytes int) (*searcher, error) {
-	if len(inputPattern) == 0 {
-		return nil, errors.New("empty pattern")
+	return NewMultiSearcher([]string{inputPattern}, showBeforeBytes, showAfterBytes)
+}
+
+// NewMultiSearcher creates a searcher that looks for all of the byte patterns
+// parsed from inputPatterns in a single pass.
+// Typed numbers like "u32le:0xDEADBEEF" are searched for as their encoded bytes,
+// see parseTypedNumber. Otherwise see parseSearchPattern for pattern syntax.
+func NewMultiSearcher(inputPatterns []string, showBeforeBytes int, showAfterBytes int) (*searcher, error) {
+	return newTextSearcher(inputPatterns, textOptions{}, showBeforeBytes, showAfterBytes)
+}
+
+// newTextSearcher is NewMultiSearcher with textOpts applied to all patterns
+// that aren't typed numbers.
+func newTextSearcher(inputPatterns []string, textOpts textOptions, showBeforeBytes int, showAfterBytes int) (*searcher, error) {
+	textEncodings := textOpts.encodings
+	if len(textEncodings) == 0 {
+		textEncodings = []textEncoding{encodingNone}
 	}
+	patterns := make([][]byteSet, 0, len(inputPatterns))
+	labels := make([]string, 0, len(inputPatterns))
+	for i, inputPattern := range inputPatterns {
+		encodings, isTyped, err := parseTypedNumber(inputPattern)
+		if err == nil && isTyped {
+			for _, encoded := range encodings {
+				pattern := make([]byteSet, 0, len(encoded.value))
+				for _, b := range encoded.value {
+					pattern = append(pattern, singleByte(b))
+				}
+				patterns = append(patterns, pattern)
+				if len(encodings) > 1 {
+					labels = append(labels, fmt.Sprintf("%s (%s)", inputPattern, encoded.endianness))
+				} else {
+					labels = append(labels, inputPattern)
+				}
+			}
+			continue
+		}
+		if err == nil {
+			for _, encoding := range textEncodings {
+				var pattern []byteSet
+				pattern, err = parseTextPattern(inputPattern, encoding, textOpts.ignoreCase)
+				if err != nil {
+					break
+				}
+				patterns = append(patterns, pattern)
+				if len(textEncodings) > 1 {
+					labels = append(labels, fmt.Sprintf("%s (%s)", inputPattern, encoding.name))
+				} else {
+					labels = append(labels, inputPattern)
+				}
+			}
+		}
+		if err != nil {
+			if len(inputPatterns) > 1 {
+				return nil, fmt.Errorf("pattern %d %q: %v", i+1, inputPattern, err)
+			}
+			return nil, err
+		}
+	}
+	return newPatternSearcher(patterns, labels, showBeforeBytes, showAfterBytes), nil
+}
+
+// newPatternSearcher creates a searcher for already parsed patterns, labels
+// are how each pattern is identified when displaying matches.
+func newPatternSearcher(patterns [][]byteSet, labels []string, showBeforeBytes int, showAfterBytes int) *searcher {
 	s := searcher{
-		pattern:         make([]uint16, 0),
+		patterns:        patterns,
+		labels:          labels,
 		showBeforeBytes: showBeforeBytes,
 		showAfterBytes:  showAfterBytes,
+		lastMatchEnds:   make([]int, len(patterns)),
 	}
+	// Patterns of only exact bytes can use the faster ahoCorasick, the rest need a bitMatcher.
+	literals := make([][]byte, 0)
+	wildcards := make([][]byteSet, 0)
+	for i, pattern := range patterns {
+		if len(pattern) > s.maxPatternLen {
+			s.maxPatternLen = len(pattern)
+		}
+		s.lastMatchEnds[i] = -1
 
-	for i := 0; i < len(inputPattern); i++ {
-		// handle escaped sequences
-		if inputPattern[i] == '\\' {
-			if i < len(inputPattern)-1 {
-				switch inputPattern[i+1] {
-				case 'n', 'N':
-					s.pattern = append(s.pattern, uint16('\n'))
-				case 't', 'T':
-					s.pattern = append(s.pattern, uint16('\t'))
-				case 'r', 'R':
-					s.pattern = append(s.pattern, uint16('\r'))
-				case '?': // escaped '?' as normally that's a wildcard/match-any-byte
-					s.pattern = append(s.pattern, uint16('?'))
-				case 'x', 'X':
-					if i < len(inputPattern)-3 {
-						hexStr := string([]byte{inputPattern[i+2], inputPattern[i+3]})
-						if parsedByte, err := strconv.ParseUint(hexStr, 16, 8); err == nil {
-							s.pattern = append(s.pattern, uint16(parsedByte))
-							// consume additional 2 bytes (consuming 2nd byte 'x' happens further below...)
-							i += 2
-						} else {
-							return nil, fmt.Errorf("'Invalid hex sequence: '\\x%s'", hexStr)
-						}
-					} else {
-						return nil, errors.New("'\\x' without trailing 2 char hex")
-					}
-				case '\\':
-					s.pattern = append(s.pattern, uint16('\\'))
-				default:
-					return nil, fmt.Errorf("Invalid escape sequence: '%s'", string([]byte{inputPattern[i], inputPattern[i+1]}))
-				}
-				i += 1 // consume 2nd byte
-			} else {
-				return nil, errors.New("Trailing '\\'")
+		literal := make([]byte, 0, len(pattern))
+		for _, set := range pattern {
+			val, ok := set.literal()
+			if !ok {
+				break
 			}
-		} else if inputPattern[i] == '?' { // handle wildcard/match-any-single-byte
-			s.pattern = append(s.pattern, anyByte)
-		} else { // take char as-is
-			s.pattern = append(s.pattern, uint16(inputPattern[i]))
+			literal = append(literal, val)
 		}
-	}
-
-	// Don't allow entirely anyByte as that would
-	// match all bytes of input every time and is silly.
-	allAnyByte := true
-	for _, val := range s.pattern {
-		if val != anyByte {
-			allAnyByte = false
-			break
+		if len(literal) == len(pattern) {
+			literals = append(literals, literal)
+			s.literalIDs = append(s.literalIDs, i)
+		} else {
+			wildcards = append(wildcards, pattern)
+			s.wildcardIDs = append(s.wildcardIDs, i)
 		}
 	}
-	if allAnyByte {
-		return nil, errors.New("pattern cannot be all '?' (match any byte)")
+	if len(literals) > 0 {
+		s.literalMatcher = newAhoCorasick(literals)
+	}
+	if len(wildcards) > 0 {
+		s.wildcardMatcher = newBitMatcher(wildcards)
 	}
-	// pre-allocate capacity to match lenth of search pattern as we'll buffer
-	// the same amount while building a match
-	s.matchBuffer = make([]byte, 0, len(s.pattern))
-	return &s, nil
+	return &s
 }
 
 // searchMatch represents any matched search pattern.
@@ -139,19 +556,36 @@ type searchMatch struct {
 	startIndex   int
 	endIndex     int
 	matchedValue []byte
+	// Which of the searcher's patterns matched
+	patternIndex int
 	// Optional before contex to display
 	beforeBytes []byte
 	// Optional after contex to display
 	afterBytes []byte
 }
 
-// searcher is used to find a given pattern of bytes (with optional match-any-byte placeholder(s))
-// and captures all non-overlapping hits (first match wins).
+// searcher is used to find given patterns of bytes (with optional wildcards, byte classes, etc)
+// and captures all non-overlapping hits (first match wins) of each pattern unless overlapping is set.
 type searcher struct {
-	pattern []uint16 // bigger than byte to allow placeholder for "any"
-	// buffer current match state in order to match across chunks
-	matchBuffer []byte
-	matches     []searchMatch
+	patterns [][]byteSet
+	// Original pattern input, used to label which pattern matched.
+	labels        []string
+	maxPatternLen int
+	// Track partial matches in order to match across chunks.
+	// Literal patterns are found via literalMatcher, ones with wildcards via wildcardMatcher.
+	// Each reports ids relative to its own patterns which are mapped back to
+	// the index in patterns via literalIDs and wildcardIDs.
+	literalMatcher  *ahoCorasick
+	literalIDs      []int
+	wildcardMatcher *bitMatcher
+	wildcardIDs     []int
+	// reused between bytes to collect matched pattern indexes
+	matchedIDs []int
+	matches    []searchMatch
+	// Whether to report matches that overlap previous ones.
+	overlapping bool
+	// endIndex of the last reported match of each pattern, used to skip overlapping matches.
+	lastMatchEnds []int
 	// How much context before to buffer for a match for informational purposes
 	showBeforeBytes int
 	// How mcuh context after to buffer for a match for informational purposes.
@@ -160,6 +594,26 @@ type searcher struct {
 	// Used to calculate absolute match start/end indexes--not just relative to
 	// currently processed chunk.
 	bytesConsumed int
+	// The last showBeforeBytes+maxPatternLen bytes from previous update calls.
+	// Used to capture before context for matches near the start of a chunk.
+	history []byte
+	// When set, patterns are searched for as regular expressions instead.
+	// See updateRegexes.
+	regexes []*regexp.Regexp
+	// Each of regexes preceded by any byte, used to search from partway
+	// through the data with that byte as context.
+	resumeRegexes []*regexp.Regexp
+	// Absolute index to resume searching from for each regex.
+	regexResumeAt []int
+}
+
+// label returns the original input of the pattern a match is for, or empty
+// string if only searching for a single pattern.
+func (s *searcher) label(m searchMatch) string {
+	if len(s.labels) < 2 {
+		return ""
+	}
+	return s.labels[m.patternIndex]
 }
 
 // update consumes given chunk of data and checks for matches.
@@ -169,29 +623,114 @@ type searcher struct {
 // 100 bytes were passed to update, and then a match occurs on the 2nd byte of
 // the subsequent call, the match start would be at index 101 (102nd, as 0 based...)
 // not the index 1 (2nd byte) of the current chunk.
+// Matches found in earlier calls that are still waiting on after context
+// get it filled in from the start of inChunk.
+// Matches are in order of where they end, ties are in order of pattern index.
 func (s *searcher) update(inChunk []byte) {
-	patternPos := len(s.matchBuffer)
-	for i := 0; i < len(inChunk); i++ {
-		if s.pattern[patternPos] == anyByte || uint16(inChunk[i]) == s.pattern[patternPos] {
-			s.matchBuffer = append(s.matchBuffer, inChunk[i])
-			patternPos++
-			if len(s.matchBuffer) == len(s.pattern) {
-				copied := make([]byte, len(s.matchBuffer))
-				copy(copied, s.matchBuffer)
-				newMatch := searchMatch{
-					matchedValue: copied,
-					endIndex:     s.bytesConsumed + i,
-					startIndex:   (s.bytesConsumed + i) - (len(copied) - 1),
+	for i := range s.matches {
+		if missing := s.showAfterBytes - len(s.matches[i].afterBytes); missing > 0 {
+			if missing > len(inChunk) {
+				missing = len(inChunk)
+			}
+			s.matches[i].afterBytes = append(s.matches[i].afterBytes, inChunk[:missing]...)
+		}
+	}
+
+	if len(s.regexes) > 0 {
+		s.updateRegexes(inChunk, false)
+	} else {
+		for i := 0; i < len(inChunk); i++ {
+			s.matchedIDs = s.matchedIDs[:0]
+			if s.literalMatcher != nil {
+				for _, id := range s.literalMatcher.step(inChunk[i]) {
+					s.matchedIDs = append(s.matchedIDs, s.literalIDs[id])
 				}
-				s.matches = append(s.matches, newMatch)
-				s.matchBuffer = s.matchBuffer[:0]
-				patternPos = 0
 			}
-		} else {
-			// TODO: instead of clearning completely, see if partial overlap match
-			s.matchBuffer = s.matchBuffer[:0]
-			patternPos = 0
+			if s.wildcardMatcher != nil {
+				for _, id := range s.wildcardMatcher.step(inChunk[i]) {
+					s.matchedIDs = append(s.matchedIDs, s.wildcardIDs[id])
+				}
+			}
+			if len(s.matchedIDs) == 0 {
+				continue
+			}
+			if len(s.matchedIDs) > 1 {
+				sort.Ints(s.matchedIDs)
+			}
+			endIndex := s.bytesConsumed + i
+			for _, patternIndex := range s.matchedIDs {
+				startIndex := endIndex - (len(s.patterns[patternIndex]) - 1)
+				if !s.overlapping && startIndex <= s.lastMatchEnds[patternIndex] {
+					// overlaps previous match, first match wins.
+					continue
+				}
+				s.addMatch(inChunk, startIndex, endIndex, patternIndex)
+			}
+		}
+	}
+
+	// remember the tail of consumed data for subsequent calls' before context
+	keep := s.showBeforeBytes + s.maxPatternLen
+	if len(inChunk) >= keep {
+		s.history = append(s.history[:0], inChunk[len(inChunk)-keep:]...)
+	} else {
+		s.history = append(s.history, inChunk...)
+		if len(s.history) > keep {
+			s.history = append(s.history[:0], s.history[len(s.history)-keep:]...)
 		}
 	}
 	s.bytesConsumed += len(inChunk)
 }
+
+// finish is called once there is no more input, any matches that were waiting
+// to see if more data would change them are added.
+func (s *searcher) finish() {
+	if len(s.regexes) > 0 {
+		s.updateRegexes(nil, true)
+	}
+}
+
+// addMatch records a match of the given pattern spanning absolute indexes
+// startIndex thru endIndex (inclusive) that ends in either s.history or inChunk.
+func (s *searcher) addMatch(inChunk []byte, startIndex, endIndex int, patternIndex int) {
+	newMatch := searchMatch{
+		matchedValue: s.lookBack(inChunk, startIndex, endIndex+1),
+		endIndex:     endIndex,
+		startIndex:   startIndex,
+		patternIndex: patternIndex,
+	}
+	newMatch.beforeBytes = s.lookBack(inChunk, newMatch.startIndex-s.showBeforeBytes, newMatch.startIndex)
+	afterEnd := endIndex + 1 + s.showAfterBytes
+	if afterEnd > s.bytesConsumed+len(inChunk) {
+		afterEnd = s.bytesConsumed + len(inChunk)
+	}
+	newMatch.afterBytes = append(make([]byte, 0, s.showAfterBytes), s.lookBack(inChunk, endIndex+1, afterEnd)...)
+	s.matches = append(s.matches, newMatch)
+	s.lastMatchEnds[patternIndex] = endIndex
+}
+
+// lookBack returns a copy of the consumed data in absolute index range [start, end)
+// where the data is made up of s.history followed by the currently processed inChunk.
+// The start is clamped to the earliest data still available.
+func (s *searcher) lookBack(inChunk []byte, start, end int) []byte {
+	historyStart := s.bytesConsumed - len(s.history)
+	if start < historyStart {
+		start = historyStart
+	}
+	if end <= start {
+		return []byte{}
+	}
+	out := make([]byte, 0, end-start)
+	if start < s.bytesConsumed {
+		histEnd := end
+		if histEnd > s.bytesConsumed {
+			histEnd = s.bytesConsumed
+		}
+		out = append(out, s.history[start-historyStart:histEnd-historyStart]...)
+		start = histEnd
+	}
+	if end > start {
+		out = append(out, inChunk[start-s.bytesConsumed:end-s.bytesConsumed]...)
+	}
+	return out
+}
@@ -1,8 +1,15 @@
 package commands
 
 import (
+	"io/ioutil"
+	"math"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
 )
 
 func Test_searcher_update_matchAcrossCalls(t *testing.T) {
@@ -74,8 +81,362 @@ func Test_searcher_update_anyByteMatches(t *testing.T) {
 	}
 }
 
-// TODO: overlap
-// TODO: overlap more often because of wild card?
+func Test_searcher_update_beforeAfterAcrossCalls(t *testing.T) {
+	s, err := NewSearcher("ab", 3, 4)
+	if err != nil {
+		t.Fatalf("Unexpected err creating searcher: %s", err)
+	}
+
+	// before context comes from previous calls, after context from subsequent ones:
+	s.update([]byte("hel"))
+	s.update([]byte("lo"))
+	s.update([]byte("ab"))
+	s.update([]byte("x"))
+	if len(s.matches) != 1 {
+		t.Fatalf("Expected %d matches, got: %d", 1, len(s.matches))
+	}
+	if !reflect.DeepEqual(s.matches[0].beforeBytes, []byte("llo")) {
+		t.Errorf("Unexpected before value, expect: %q, got: %q", "llo", s.matches[0].beforeBytes)
+	}
+	if !reflect.DeepEqual(s.matches[0].afterBytes, []byte("x")) {
+		t.Errorf("Unexpected after value, expect: %q, got: %q", "x", s.matches[0].afterBytes)
+	}
+	s.update([]byte("yzzzzz"))
+	if !reflect.DeepEqual(s.matches[0].afterBytes, []byte("xyzz")) {
+		t.Errorf("Unexpected after value, expect: %q, got: %q", "xyzz", s.matches[0].afterBytes)
+	}
+
+	// before context is truncated at the start of input:
+	s, _ = NewSearcher("ab", 3, 4)
+	s.update([]byte("xab"))
+	if !reflect.DeepEqual(s.matches[0].beforeBytes, []byte("x")) {
+		t.Errorf("Unexpected before value, expect: %q, got: %q", "x", s.matches[0].beforeBytes)
+	}
+}
+
+func Test_Search(t *testing.T) {
+	var writer strings.Builder
+	reader := strings.NewReader(
+		"The rain in Spain falls mainly in the plains.",
+	)
+	expected := `match 1 at 2B-2F (5 bytes):
+           20:                      74 68 65 20 70 6C 61 69 6E 
+                                     t  h  e     p  l  a  i  n 
+           30: 73 2E 
+                s  . 
+`
+	ioInfo := options.IOInfo{}
+	// NOTE: offset is enforced when creating input, search only uses it for displayed offsets
+	err := Search(&writer, input.NewFixedLengthBufferedReader(reader), ioInfo,
+		options.Options{Offset: 5, Limit: math.MaxInt64, Display: options.DisplayOptions{Width: 16}},
+		[]string{"plain", "4:2"})
+	result := writer.String()
+	if result != expected {
+		t.Errorf("Unexpected output.\nExpected:\n%q\n\ngot:\n%q", expected, result)
+	}
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// placeholder for anyByteSet in test cases
+const anyByte = 256
+
+// toByteSets converts a pattern of exact bytes and anyByte placeholders to
+// what each position of the pattern matches.
+func toByteSets(vals []uint16) []byteSet {
+	sets := make([]byteSet, 0, len(vals))
+	for _, val := range vals {
+		if val == anyByte {
+			sets = append(sets, anyByteSet)
+		} else {
+			sets = append(sets, singleByte(byte(val)))
+		}
+	}
+	return sets
+}
+
+// matchStarts returns the start index of all matches found.
+func matchStarts(matches []searchMatch) []int {
+	starts := make([]int, 0, len(matches))
+	for _, m := range matches {
+		starts = append(starts, m.startIndex)
+	}
+	return starts
+}
+
+func Test_searcher_update_partialMatches(t *testing.T) {
+	type testCase struct {
+		pattern        string
+		overlapping    bool
+		data           string
+		expectedStarts []int
+	}
+	cases := []testCase{
+		// mismatch after a partial match must not throw away a shorter partial match:
+		{"aab", false, "aaab", []int{1}},
+		{"abac", false, "ababac", []int{2}},
+		{"a?b", false, "aaab", []int{1}},
+		{"a?b", false, "aa?b", []int{1}},
+		// first match wins:
+		{"aa", false, "aaaa", []int{0, 2}},
+		{"aa", false, "aaaaa", []int{0, 2}},
+		{"aba", false, "ababa", []int{0}},
+		{"a?a", false, "aaaaa", []int{0}},
+		// overlapping:
+		{"aa", true, "aaaa", []int{0, 1, 2}},
+		{"aba", true, "ababa", []int{0, 2}},
+		{"a?a", true, "aaaaa", []int{0, 1, 2}},
+		{"?b", true, "bbb", []int{0, 1}},
+	}
+	for _, c := range cases {
+		s, err := NewSearcher(c.pattern, 0, 0)
+		if err != nil {
+			t.Fatalf("Unexpected err creating searcher: %s", err)
+		}
+		s.overlapping = c.overlapping
+		s.update([]byte(c.data))
+		if starts := matchStarts(s.matches); !reflect.DeepEqual(starts, c.expectedStarts) {
+			t.Errorf("pattern: %q, data: %q, overlapping: %v, expected starts: %v, got: %v",
+				c.pattern, c.data, c.overlapping, c.expectedStarts, starts)
+		}
+	}
+}
+
+// Tests that the same matches are found no matter how input is chunked.
+func Test_searcher_update_anyChunkSize(t *testing.T) {
+	data := []byte(strings.Repeat("xaabaabaaabaab\x00aab", 9) + strings.Repeat("baab", 25))
+	// pattern longer than 64 bytes to span more than one word of matcher state:
+	long := strings.Repeat("?aab", 20)
+	for _, patterns := range [][]string{{"aabaab"}, {"a?baa"}, {"b"}, {long}, {"aab", "ba", "a?b", "\\x00aa", long}} {
+		for _, overlapping := range []bool{false, true} {
+			whole, _ := NewMultiSearcher(patterns, 3, 3)
+			whole.overlapping = overlapping
+			whole.update(data)
+			if len(whole.matches) == 0 {
+				t.Fatalf("patterns: %q, expected some matches", patterns)
+			}
+			for chunkSize := 1; chunkSize < whole.maxPatternLen+3; chunkSize++ {
+				chunked, _ := NewMultiSearcher(patterns, 3, 3)
+				chunked.overlapping = overlapping
+				for i := 0; i < len(data); i += chunkSize {
+					end := i + chunkSize
+					if end > len(data) {
+						end = len(data)
+					}
+					chunked.update(data[i:end])
+				}
+				if !reflect.DeepEqual(chunked.matches, whole.matches) {
+					t.Errorf("patterns: %q, overlapping: %v, chunk size: %d, expected matches: %v, got: %v",
+						patterns, overlapping, chunkSize, matchStarts(whole.matches), matchStarts(chunked.matches))
+				}
+			}
+		}
+	}
+}
+
+func Test_NewMultiSearcher_update(t *testing.T) {
+	s, err := NewMultiSearcher([]string{"\\x7fELF", "ELF", "PK\\x03?", "MZ", "?Z"}, 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected err creating searcher: %s", err)
+	}
+	s.update([]byte("MZ..\x7fEL"))
+	s.update([]byte("F..PK\x03\x04..ELF"))
+	type hit struct {
+		start        int
+		patternIndex int
+	}
+	expected := []hit{{0, 3}, {0, 4}, {4, 0}, {5, 1}, {10, 2}, {16, 1}}
+	got := make([]hit, 0)
+	for _, m := range s.matches {
+		got = append(got, hit{m.startIndex, m.patternIndex})
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected matches, expected: %v, got: %v", expected, got)
+	}
+	if !reflect.DeepEqual(s.matches[4].matchedValue, []byte("PK\x03\x04")) {
+		t.Errorf("Unexpected match value, expect: %q, got: %q", "PK\x03\x04", s.matches[4].matchedValue)
+	}
+
+	_, err = NewMultiSearcher([]string{"ok", "bad\\x"}, 0, 0)
+	expectedErr := "pattern 2 \"bad\\\\x\": '\\x' without trailing 2 char hex"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Unexpected err, expected: %q, got: %v", expectedErr, err)
+	}
+}
+
+func Test_Search_multiplePatterns(t *testing.T) {
+	var writer strings.Builder
+	reader := strings.NewReader(
+		"The rain in Spain falls mainly in the plains.",
+	)
+	expected := `match 1 at 6-9 (4 bytes) pattern 2: r?in:
+            0:       54 68 65 20 72 61 69 6E 20 
+                      T  h  e     r  a  i  n    
+
+match 2 at E-12 (5 bytes) pattern 1: Spain:
+            0:                               20 69 6E 20 53 70 
+                                                 i  n     S  p 
+           10: 61 69 6E 20 
+                a  i  n    
+`
+	ioInfo := options.IOInfo{}
+	err := Search(&writer, input.NewFixedLengthBufferedReader(reader), ioInfo,
+		options.Options{Offset: 2, Limit: math.MaxInt64, Display: options.DisplayOptions{Width: 16}},
+		[]string{"--context", "4:1", "Spain", "r?in"})
+	result := writer.String()
+	if result != expected {
+		t.Errorf("Unexpected output.\nExpected:\n%q\n\ngot:\n%q", expected, result)
+	}
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_Search_outputModes(t *testing.T) {
+	type testCase struct {
+		cmdOptions     []string
+		expected       string
+		expectedErrStr string
+	}
+	cases := []testCase{
+		{[]string{"--count", "ain"}, "4\n", ""},
+		{[]string{"-c", "-m", "2", "ain"}, "2\n", ""},
+		{[]string{"--offsets", "ain"}, "0x7\n0x10\n0x1B\n0x2A\n", ""},
+		{[]string{"--offsets", "--decimal", "--max-count", "3", "ain"}, "7\n16\n27\n", ""},
+		{[]string{"--offsets", "--first", "ain"}, "0x7\n", ""},
+		{[]string{"-q", "ain"}, "", ""},
+		{[]string{"--first", "--context", "1:1", "ain"}, "match 1 at 7-9 (3 bytes):\n            0:                   72 61 69 6E 20 \n                                  r  a  i  n    \n", ""},
+		// flags after patterns:
+		{[]string{"ain", "-c"}, "4\n", ""},
+		{[]string{"ain", "--offsets", "-m", "1"}, "0x7\n", ""},
+		{[]string{"-c", "ain", "--ignore-case", "the"}, "6\n", ""},
+		{[]string{"-q", "--", "-c"}, "", "No matches found"},
+		// no matches:
+		{[]string{"--count", "xyz"}, "0\n", "No matches found"},
+		{[]string{"-q", "xyz"}, "", "No matches found"},
+		{[]string{"xyz"}, "", "No matches found"},
+		// invalid options:
+		{[]string{"--count", "--offsets", "ain"}, "", "Only one of --count, --offsets or --quiet can be used at a time"},
+		{[]string{"--decimal", "ain"}, "", "--decimal requires --offsets"},
+		{[]string{"-m", "-1", "ain"}, "", "--max-count must be >= 0, got: -1"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		reader := strings.NewReader("The rain in Spain falls mainly in the plains.")
+		err := Search(&writer, input.NewFixedLengthBufferedReader(reader), options.IOInfo{},
+			options.Options{Offset: 2, Limit: math.MaxInt64, Display: options.DisplayOptions{Width: 16}},
+			c.cmdOptions)
+		if err == nil && c.expectedErrStr != "" {
+			t.Errorf("options: %q, err nil, but expected: %q", c.cmdOptions, c.expectedErrStr)
+		}
+		if err != nil && err.Error() != c.expectedErrStr {
+			t.Errorf("options: %q, unexpected err, expected: %q, got: %q", c.cmdOptions, c.expectedErrStr, err.Error())
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("options: %q, unexpected output, expected: %q, got: %q", c.cmdOptions, c.expected, result)
+		}
+	}
+}
+
+func Test_Search_multipleRanges(t *testing.T) {
+	data := "The rain in Spain falls mainly in the plains."
+	// "plains." + "ai" + "n" + "Sp" + "a" + "in" where the last 3 are next to each other
+	ranges := []options.Range{{Offset: 38, Limit: 7}, {Offset: 5, Limit: 2}, {Offset: 16, Limit: 1},
+		{Offset: 12, Limit: 2}, {Offset: 14, Limit: 1}, {Offset: 15, Limit: 2}}
+	var joined strings.Builder
+	for _, r := range ranges {
+		joined.WriteString(data[r.Offset : r.Offset+r.Limit])
+	}
+	type testCase struct {
+		cmdOptions []string
+		expected   string
+	}
+	cases := []testCase{
+		// "ai" + "n" isn't in the input, but "Sp" + "a" + "in" is
+		{[]string{"--offsets", "ain"}, "0x28\n0xE\n"},
+		{[]string{"-c", "ain"}, "2\n"},
+		// context stops at the edges of the ranges
+		{[]string{"--context", "4:4", "s.", "Spain"}, `match 1 at 2B-2C (2 bytes) pattern 1: s.:
+           20:                      6C 61 69 6E 73 2E 
+                                     l  a  i  n  s  . 
+
+match 2 at C-10 (5 bytes) pattern 2: Spain:
+            0:                                     53 70 61 69 
+                                                    S  p  a  i 
+           10: 6E 
+                n 
+`},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		err := Search(&writer, input.NewFixedLengthBufferedReader(strings.NewReader(joined.String())), options.IOInfo{},
+			options.Options{Ranges: ranges, Limit: math.MaxInt64, Display: options.DisplayOptions{Width: 16}},
+			c.cmdOptions)
+		if err != nil {
+			t.Errorf("options: %q, unexpected err: %v", c.cmdOptions, err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("options: %q, unexpected output, expected:\n%s\ngot:\n%s", c.cmdOptions, c.expected, result)
+		}
+	}
+}
+
+// Tests that the examples in the usage text work.
+func Test_Search_usageExamples(t *testing.T) {
+	data := "PK\x03\x04hello.txt\x00C\x00:\x00\\\x00W\x00i\x00n\x00d\x00o\x00w\x00s\x00 \x01\x02\x03 -x 0123 abc" +
+		"\n\r\t\\?[]{} \xEF\xBE\xAD\xDE \xFF\xFE \xC3\xF5\x48\x40 \x80\x00 u8:12"
+	type testCase struct {
+		usage    string
+		flags    []string
+		patterns []string
+	}
+	cases := []testCase{
+		{`search --regex 'PK\x03\x04[ -~]{4,30}'`, []string{"--regex"}, []string{`PK\x03\x04[ -~]{4,30}`}},
+		{`search --encoding utf16le 'C:\\Windows'`, []string{"--encoding", "utf16le"}, []string{`C:\\Windows`}},
+		{`search -- -x`, []string{"--"}, []string{"-x"}},
+		{`search \x01\x02\x03 5:7`, nil, []string{`\x01\x02\x03`}},
+		{`\xAB \n \r \t \\`, nil, []string{`\xEF`, `\n`, `\r`, `\t`, `\\`}},
+		{`\x4? and \x?F`, nil, []string{`\x4?`, `\x?F`}},
+		{`[\x30-\x39] [abc] [^\x00]`, nil, []string{`[\x30-\x39]`, `[abc]`, `[^\x00]`}},
+		{`{40/F0}`, nil, []string{`{40/F0}`}},
+		{`\? \[ \] \{ \}`, nil, []string{`\?`, `\[`, `\]`, `\{`, `\}`}},
+		{`u32le:0xDEADBEEF i16be:-2 f32:3.14 u16:0x40*2`, nil, []string{"u32le:0xDEADBEEF", "i16be:-2", "f32:3.14", "u16:0x40*2"}},
+		{`u8\x3A12`, nil, []string{`u8\x3A12`}},
+	}
+	for _, c := range cases {
+		if !strings.Contains(searchUsage, c.usage) {
+			t.Errorf("example not in usage: %s", c.usage)
+		}
+		for _, pattern := range c.patterns {
+			cmdOptions := append(append([]string{"-q"}, c.flags...), pattern)
+			err := Search(&strings.Builder{}, input.NewFixedLengthBufferedReader(strings.NewReader(data)), options.IOInfo{},
+				options.Options{Limit: math.MaxInt64}, cmdOptions)
+			if err != nil {
+				t.Errorf("example: %s, options: %q, expected a match, got err: %v", c.usage, cmdOptions, err)
+			}
+		}
+	}
+}
+
+func Test_readPatternFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "hax_patterns")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# magic values\n\\x7fELF\r\n\nMZ\n\\x23!\n")
+	f.Close()
+
+	patterns, err := readPatternFile(f.Name())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"\\x7fELF", "MZ", "\\x23!"}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("Unexpected patterns, expected: %q, got: %q", expected, patterns)
+	}
+}
 
 func Test_parseBeforeAfter(t *testing.T) {
 	type testCase struct {
@@ -165,8 +526,8 @@ func Test_NewSearcher(t *testing.T) {
 		if s == nil {
 			continue
 		}
-		if !reflect.DeepEqual(s.pattern, c.expectedPattern) {
-			t.Errorf("input: %q, unexpected pattern value, expected: %q, got: %q", c.inputPattern, c.expectedPattern, s.pattern)
+		if !reflect.DeepEqual(s.patterns[0], toByteSets(c.expectedPattern)) {
+			t.Errorf("input: %q, unexpected pattern value, expected: %v, got: %v", c.inputPattern, toByteSets(c.expectedPattern), s.patterns[0])
 		}
 		if s.showBeforeBytes != c.inputBeforeBytes {
 			t.Errorf("input: %q, unexpected showBeforeBytes value, expected: %d, got: %d", c.inputPattern, c.inputBeforeBytes, s.showBeforeBytes)
@@ -174,12 +535,8 @@ func Test_NewSearcher(t *testing.T) {
 		if s.showAfterBytes != c.inputAfterBytes {
 			t.Errorf("input: %q, unexpected showAfterBytes value, expected: %d, got: %d", c.inputPattern, c.inputAfterBytes, s.showAfterBytes)
 		}
-		// should pre-allocate needed buffer space
-		if cap(s.matchBuffer) != len(s.pattern) {
-			t.Errorf("input: %q, unexpected matchBuffer cap() value, expected: %d, got: %d", c.inputPattern, len(c.expectedPattern), cap(s.matchBuffer))
-		}
-		if len(s.matchBuffer) != 0 {
-			t.Errorf("input: %q, unexpected matchBuffer len() value, expected: %d, got: %d", c.inputPattern, 0, len(s.matchBuffer))
+		if s.literalMatcher == nil && s.wildcardMatcher == nil {
+			t.Errorf("input: %q, expected a matcher for pattern", c.inputPattern)
 		}
 	}
 }
@@ -49,6 +49,7 @@ func Strings(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo o
 	// track start of current string
 	curStringStart := int64(-1)
 	first := true // used to know when to omit preceeding newline as first line doesn't need it
+	cursor := rangeCursor{offset: opts.Offset, ranges: opts.Ranges}
 
 	for {
 		var n int
@@ -71,9 +72,13 @@ func Strings(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo o
 		}
 
 		for i := 0; i < n; i++ {
+			offset, newRange := cursor.at(bytesRead + int64(i))
+			if newRange { // end strings at gaps between ranges
+				flushCurString(&curStrBuilder, &outBuilder, &first, &opts, &curStringStart, ioInfo.OutputPretty, minStringLen, maxStringLen)
+			}
 			if buf[i] > 31 && buf[i] < 127 {
 				if curStringStart < 0 { // not set
-					curStringStart = opts.Offset + bytesRead + int64(i)
+					curStringStart = offset
 				}
 				curStrBuilder.WriteByte(buf[i])
 			} else {
@@ -125,3 +130,30 @@ func flushCurString(curStrBuilder, outBuilder *strings.Builder, first *bool, opt
 	}
 	*curStringStart = -1
 }
+
+// rangeCursor gives the input offset of a position in the data read, which
+// for multiple --range's is each range's data one after another.
+type rangeCursor struct {
+	offset int64 // of the data when there aren't multiple ranges
+	ranges []options.Range
+	index  int   // of the range the last position was in
+	start  int64 // of ranges[index] in the data
+}
+
+// at gives the input offset of pos and whether pos starts a range that
+// doesn't follow on from the previous one in the input, so anything spanning
+// pos isn't really in the input. Positions must not decrease between calls.
+func (c *rangeCursor) at(pos int64) (offset int64, newRange bool) {
+	if len(c.ranges) == 0 {
+		return c.offset + pos, false
+	}
+	// where pos would be if still in the same range
+	prevEnd := c.ranges[c.index].Offset + pos - c.start
+	for c.index < len(c.ranges)-1 && pos >= c.start+c.ranges[c.index].Limit {
+		c.start += c.ranges[c.index].Limit
+		c.index++
+		newRange = true
+	}
+	offset = c.ranges[c.index].Offset + pos - c.start
+	return offset, newRange && offset != prevEnd
+}
@@ -86,3 +86,24 @@ func Test_Strings_MinMaxLen(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 }
+
+// Tests that strings end at gaps between multiple ranges and offsets are of the input
+func Test_Strings_multipleRanges(t *testing.T) {
+	data := "hello there, this took 10 seconds"
+	// "hello" + "econds" + "the" + "re" where the last 2 are next to each other
+	ranges := []options.Range{{Offset: 0, Limit: 5}, {Offset: 27, Limit: 6}, {Offset: 6, Limit: 3}, {Offset: 9, Limit: 2}}
+	var joined strings.Builder
+	for _, r := range ranges {
+		joined.WriteString(data[r.Offset : r.Offset+r.Limit])
+	}
+	expected := "            0:\thello\n           1B:\teconds\n            6:\tthere"
+	var writer strings.Builder
+	err := Strings(&writer, input.NewFixedLengthBufferedReader(strings.NewReader(joined.String())), options.IOInfo{},
+		options.Options{Ranges: ranges, Limit: math.MaxInt64}, []string{})
+	if result := writer.String(); result != expected {
+		t.Errorf("Unexpected output.\nExpected:\n%q\n\ngot:\n%q", expected, result)
+	}
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
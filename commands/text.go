@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// textEncoding is how the text of a search pattern is encoded before searching.
+type textEncoding struct {
+	name string
+	// bytes per code unit, 0 means the pattern is searched for as given.
+	unitSize  int
+	bigEndian bool
+}
+
+var (
+	encodingNone    = textEncoding{}
+	encodingUtf16le = textEncoding{name: "utf16le", unitSize: 2}
+	encodingUtf16be = textEncoding{name: "utf16be", unitSize: 2, bigEndian: true}
+	encodingUtf32le = textEncoding{name: "utf32le", unitSize: 4}
+	encodingUtf32be = textEncoding{name: "utf32be", unitSize: 4, bigEndian: true}
+)
+
+// textOptions changes how non typed number patterns are interpreted.
+type textOptions struct {
+	// ascii letters match either case
+	ignoreCase bool
+	// search for the pattern text in each of these encodings.
+	// Empty means as given (same as encodingNone).
+	encodings []textEncoding
+}
+
+// parseTextEncodings parses an encoding name. "utf16" and "utf32" mean
+// both little and big endian like typed numbers without le/be.
+func parseTextEncodings(name string) ([]textEncoding, error) {
+	switch strings.ToLower(strings.Replace(name, "-", "", -1)) {
+	case "", "none", "utf8":
+		return []textEncoding{encodingNone}, nil
+	case "utf16le":
+		return []textEncoding{encodingUtf16le}, nil
+	case "utf16be":
+		return []textEncoding{encodingUtf16be}, nil
+	case "utf16":
+		return []textEncoding{encodingUtf16le, encodingUtf16be}, nil
+	case "utf32le":
+		return []textEncoding{encodingUtf32le}, nil
+	case "utf32be":
+		return []textEncoding{encodingUtf32be}, nil
+	case "utf32":
+		return []textEncoding{encodingUtf32le, encodingUtf32be}, nil
+	default:
+		return nil, fmt.Errorf("Invalid encoding: %q, expect one of: utf8, utf16, utf16le, utf16be, utf32, utf32le, utf32be", name)
+	}
+}
+
+// foldCase adds the other case of any ascii letters in the set.
+func (b byteSet) foldCase() byteSet {
+	folded := b
+	for c := byte('a'); c <= 'z'; c++ {
+		upper := c - 'a' + 'A'
+		if b.contains(c) {
+			folded.add(upper)
+		}
+		if b.contains(upper) {
+			folded.add(c)
+		}
+	}
+	return folded
+}
+
+// parseTextPattern parses a search pattern (see parseSearchPattern) and
+// applies options. For encodings other than encodingNone, each character of
+// the pattern becomes a code unit:
+//
+//	non-ascii text is decoded as UTF-8 and encoded as the given encoding
+//	escapes, classes and value/masks give the low byte, the rest are zero.
+//	  ex: utf16le \x4? matches 40 00 thru 4F 00
+//	'?' matches any code unit
+func parseTextPattern(inputPattern string, encoding textEncoding, ignoreCase bool) ([]byteSet, error) {
+	if encoding.unitSize == 0 {
+		pattern, err := parseSearchPattern(inputPattern)
+		if err != nil || !ignoreCase {
+			return pattern, err
+		}
+		for i := range pattern {
+			pattern[i] = pattern[i].foldCase()
+		}
+		return pattern, nil
+	}
+
+	if len(inputPattern) == 0 {
+		return nil, errors.New("empty pattern")
+	}
+	pattern := make([]byteSet, 0, len(inputPattern)*encoding.unitSize)
+	for i := 0; i < len(inputPattern); {
+		if inputPattern[i] >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(inputPattern[i:])
+			if r == utf8.RuneError && size == 1 {
+				return nil, fmt.Errorf("Invalid UTF-8 in pattern at byte %d", i)
+			}
+			units := []uint32{uint32(r)}
+			if encoding.unitSize == 2 {
+				units = units[:0]
+				for _, unit := range utf16.Encode([]rune{r}) {
+					units = append(units, uint32(unit))
+				}
+			}
+			for _, unit := range units {
+				pattern = append(pattern, encodeUnit(unit, encoding)...)
+			}
+			i += size
+			continue
+		}
+
+		set, consumed, err := parsePatternElement(inputPattern[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += consumed
+		if ignoreCase {
+			set = set.foldCase()
+		}
+		unit := make([]byteSet, encoding.unitSize)
+		for j := range unit {
+			unit[j] = singleByte(0)
+		}
+		if set == anyByteSet {
+			for j := range unit {
+				unit[j] = anyByteSet
+			}
+		} else if encoding.bigEndian {
+			unit[len(unit)-1] = set
+		} else {
+			unit[0] = set
+		}
+		pattern = append(pattern, unit...)
+	}
+
+	if err := checkNotAllWildcards(pattern); err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
+// encodeUnit gives the exact bytes of a single code unit.
+func encodeUnit(unit uint32, encoding textEncoding) []byteSet {
+	sets := make([]byteSet, encoding.unitSize)
+	for j := range sets {
+		shift := uint(j * 8)
+		if encoding.bigEndian {
+			shift = uint((encoding.unitSize - 1 - j) * 8)
+		}
+		sets[j] = singleByte(byte(unit >> shift))
+	}
+	return sets
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseTextPattern(t *testing.T) {
+	type testCase struct {
+		input          string
+		encoding       textEncoding
+		ignoreCase     bool
+		expected       []string // bytes in the set at each position
+		expectedErrStr string
+	}
+	cases := []testCase{
+		{"aB1", encodingNone, false, []string{"a", "B", "1"}, ""},
+		{"aB1", encodingNone, true, []string{"Aa", "Bb", "1"}, ""},
+		{"[a-c]", encodingNone, true, []string{"ABCabc"}, ""},
+		{"hi", encodingUtf16le, false, []string{"h", "\x00", "i", "\x00"}, ""},
+		{"hi", encodingUtf16be, false, []string{"\x00", "h", "\x00", "i"}, ""},
+		{"h", encodingUtf32le, true, []string{"Hh", "\x00", "\x00", "\x00"}, ""},
+		{"h", encodingUtf32be, false, []string{"\x00", "\x00", "\x00", "h"}, ""},
+		{"\\xFF", encodingUtf16le, false, []string{"\xFF", "\x00"}, ""},
+		// '?' is any code unit:
+		{"a?", encodingUtf16be, false, []string{"\x00", "a", string(setBytes(anyByteSet)), string(setBytes(anyByteSet))}, ""},
+		// non-ascii is taken as utf-8:
+		{"é", encodingUtf16le, false, []string{"\xE9", "\x00"}, ""},
+		{"€", encodingUtf16be, false, []string{"\x20", "\xAC"}, ""},
+		{"😀", encodingUtf16le, false, []string{"\x3D", "\xD8", "\x00", "\xDE"}, ""},
+		{"😀", encodingUtf32be, false, []string{"\x00", "\x01", "\xF6", "\x00"}, ""},
+		// errors:
+		{"", encodingUtf16le, false, nil, "empty pattern"},
+		{"??", encodingUtf16le, false, nil, "pattern cannot be all '?' (match any byte)"},
+		{"a\xFFb", encodingUtf16le, false, nil, "Invalid UTF-8 in pattern at byte 1"},
+		{"a\\", encodingUtf16le, false, nil, "Trailing '\\'"},
+	}
+	for _, c := range cases {
+		pattern, err := parseTextPattern(c.input, c.encoding, c.ignoreCase)
+		if err == nil && c.expectedErrStr != "" {
+			t.Errorf("input: %q, err nil, but expected: %q", c.input, c.expectedErrStr)
+		}
+		if err != nil && err.Error() != c.expectedErrStr {
+			t.Errorf("input: %q, unexpected err, expected: %q, got: %q", c.input, c.expectedErrStr, err.Error())
+		}
+		var got []string
+		for _, set := range pattern {
+			got = append(got, string(setBytes(set)))
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("input: %q, encoding: %q, unexpected pattern, expected: %q, got: %q", c.input, c.encoding.name, c.expected, got)
+		}
+	}
+}
+
+func Test_parseTextEncodings(t *testing.T) {
+	encodings, err := parseTextEncodings("UTF-16")
+	if err != nil {
+		t.Fatalf("Unexpected err: %v", err)
+	}
+	if !reflect.DeepEqual(encodings, []textEncoding{encodingUtf16le, encodingUtf16be}) {
+		t.Errorf("Unexpected encodings for utf16: %v", encodings)
+	}
+	if _, err := parseTextEncodings("ebcdic"); err == nil {
+		t.Errorf("Expected err for unknown encoding")
+	}
+}
+
+func Test_newTextSearcher(t *testing.T) {
+	s, err := newTextSearcher([]string{"Ab"}, textOptions{ignoreCase: true, encodings: []textEncoding{encodingUtf16le, encodingUtf16be}}, 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected err creating searcher: %s", err)
+	}
+	s.update([]byte("\x00a\x00Bxa\x00b\x00"))
+	expectedStarts := []int{0, 5}
+	if starts := matchStarts(s.matches); !reflect.DeepEqual(starts, expectedStarts) {
+		t.Errorf("Unexpected match starts, expected: %v, got: %v", expectedStarts, starts)
+	}
+	expectedLabels := []string{"Ab (utf16be)", "Ab (utf16le)"}
+	labels := make([]string, 0)
+	for _, m := range s.matches {
+		labels = append(labels, s.label(m))
+	}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Errorf("Unexpected match labels, expected: %q, got: %q", expectedLabels, labels)
+	}
+}
@@ -51,6 +51,7 @@ func StringsUtf8(writer io.Writer, reader *input.FixedLengthBufferedReader, ioIn
 	// track start of current string
 	curStringStart := int64(-1)
 	first := true // used to know when to omit preceeding newline as first line doesn't need it
+	cursor := rangeCursor{offset: opts.Offset, ranges: opts.Ranges}
 
 	for {
 		var n int
@@ -71,6 +72,10 @@ func StringsUtf8(writer io.Writer, reader *input.FixedLengthBufferedReader, ioIn
 		}
 
 		for i := 0; i < n; i++ {
+			offset, newRange := cursor.at(bytesRead + int64(i))
+			if newRange { // end strings at gaps between ranges
+				state.flush(&outBuilder, &first, &opts, &curStringStart, ioInfo.OutputPretty, minStringLen, maxStringLen)
+			}
 			if state.bytesRemaining > 0 { // in middle of unicode sequence
 				if buf[i]>>6 == 0b10 { // starts with continuation--appears valid
 					state.bytesRemaining -= 1
@@ -111,7 +116,7 @@ func StringsUtf8(writer io.Writer, reader *input.FixedLengthBufferedReader, ioIn
 			}
 			if foundSequenceStart {
 				if curStringStart < 0 { // not set
-					curStringStart = opts.Offset + bytesRead + int64(i)
+					curStringStart = offset
 				}
 				continue
 			}
@@ -123,7 +128,7 @@ func StringsUtf8(writer io.Writer, reader *input.FixedLengthBufferedReader, ioIn
 			// so any non-printable would split our output.
 			if buf[i] > 31 && buf[i] < 127 {
 				if curStringStart < 0 { // not set
-					curStringStart = opts.Offset + bytesRead + int64(i)
+					curStringStart = offset
 				}
 				state.curStrBuilder.WriteByte(buf[i])
 			} else {
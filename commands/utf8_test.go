@@ -107,3 +107,25 @@ func Test_StringsUtf8_InvalidBytes(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 }
+
+// Tests that strings and runes end at gaps between multiple ranges and offsets are of the input
+func Test_StringsUtf8_multipleRanges(t *testing.T) {
+	data := "café über naïve"
+	// "caf" + the first byte of é, then the last byte of ü + "be", then "na" + "ï" + "ve"
+	// where the last 2 are next to each other
+	ranges := []options.Range{{Offset: 0, Limit: 4}, {Offset: 7, Limit: 3}, {Offset: 12, Limit: 4}, {Offset: 16, Limit: 2}}
+	var joined strings.Builder
+	for _, r := range ranges {
+		joined.WriteString(data[r.Offset : r.Offset+r.Limit])
+	}
+	expected := "            0:\tcaf\n            8:\tbe\n            C:\tnaïve"
+	var writer strings.Builder
+	err := StringsUtf8(&writer, input.NewFixedLengthBufferedReader(strings.NewReader(joined.String())), options.IOInfo{},
+		options.Options{Ranges: ranges, Limit: math.MaxInt64}, []string{})
+	if result := writer.String(); result != expected {
+		t.Errorf("Unexpected output.\nExpected:\n%q\n\ngot:\n%q", expected, result)
+	}
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
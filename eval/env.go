@@ -0,0 +1,101 @@
+package eval
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Env provides the input data for expressions using the "size" variable or
+// reading a number from the data like "[0x3C:u32le]".
+type Env interface {
+	// Size is the length of the input data.
+	Size() (int64, error)
+	// ReadAt is like io.ReaderAt on the input data.
+	ReadAt(p []byte, offset int64) (int, error)
+}
+
+// ex: "u32le", "i16be", "u8". No endianness means little endian.
+var dataReadTypeRegex = regexp.MustCompile(`(?i)^([ui])(8|16|32|64)(le|be)?$`)
+
+// EvalExpressionEnv is EvalExpression where "size" and data reads like
+// "[offset:type]" get their values from env. env can be nil if there is no
+// input data, in which case using them is an error.
+func EvalExpressionEnv(s string, env Env) (int64, error) {
+	tokens, err := tokenize(s, env)
+	if err != nil {
+		return 0, err
+	}
+	return eval(tokens)
+}
+
+// lookupVariable gets the value of a variable, found is false if name isn't one.
+func lookupVariable(name string, env Env) (val int64, found bool, err error) {
+	if strings.ToLower(name) != "size" {
+		return 0, false, nil
+	}
+	if env == nil {
+		return 0, true, fmt.Errorf("%q needs input data, ex: --file", name)
+	}
+	val, err = env.Size()
+	return val, true, err
+}
+
+// readData evaluates "offset:type" (the inside of "[offset:type]") by reading
+// a number of the given type at offset in the input data, ex: "0x3C:u32le".
+// Type defaults to u8. A negative offset is relative to the end of the data.
+func readData(s string, env Env) (int64, error) {
+	offsetExpr, typeName := s, "u8"
+	if split := strings.LastIndex(s, ":"); split >= 0 && !strings.Contains(s[split:], "]") {
+		offsetExpr, typeName = s[:split], s[split+1:]
+	}
+	parts := dataReadTypeRegex.FindStringSubmatch(typeName)
+	if parts == nil {
+		return 0, fmt.Errorf("Invalid type %q in [%s], expect u8-u64 or i8-i64 with optional le or be", typeName, s)
+	}
+	if env == nil {
+		return 0, fmt.Errorf("[%s] needs input data to read from, ex: --file", s)
+	}
+	offset, err := EvalExpressionEnv(offsetExpr, env)
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		size, err := env.Size()
+		if err != nil {
+			return 0, err
+		}
+		offset += size
+		if offset < 0 {
+			return 0, fmt.Errorf("Failed to read [%s], offset is before the start of the data (%d bytes)", s, size)
+		}
+	}
+
+	bits, _ := strconv.Atoi(parts[2])
+	buf := make([]byte, 8)
+	n, err := env.ReadAt(buf[:bits/8], offset)
+	if n < bits/8 {
+		if err == nil || err == io.EOF {
+			return 0, fmt.Errorf("Failed to read [%s] at offset 0x%X, past the end of the data", s, offset)
+		}
+		return 0, err
+	}
+
+	var val uint64
+	if strings.ToLower(parts[3]) == "be" {
+		for _, b := range buf[:bits/8] {
+			val = val<<8 | uint64(b)
+		}
+	} else {
+		val = binary.LittleEndian.Uint64(buf)
+	}
+	if strings.ToLower(parts[1]) == "i" && bits < 64 {
+		// sign extend
+		shift := uint(64 - bits)
+		return int64(val<<shift) >> shift, nil
+	}
+	return int64(val), nil
+}
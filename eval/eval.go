@@ -175,15 +175,7 @@ func parseNumWithPossibleUnaryOperators(tokens []tokenValue) (int64, int, error)
 }
 
 func EvalExpression(s string) (int64, error) {
-	tokens, err := tokenize(s)
-	if err != nil {
-		return 0, err
-	}
-	ans, err := eval(tokens)
-	if err != nil {
-		return 0, err
-	}
-	return ans, nil
+	return EvalExpressionEnv(s, nil)
 }
 
 func DisplayEvalResult(val int64) error {
@@ -466,7 +458,15 @@ func applyOperators(opSet map[Token]bool, stack []tokenValue, opFuncs opFuncMap)
 	return updatedStack, nil
 }
 
-func tokenize(s string) ([]tokenValue, error) {
+// parseNumOrVariable parses a number, or gets the value of a variable like "size".
+func parseNumOrVariable(s string, env Env) (int64, error) {
+	if val, found, err := lookupVariable(s, env); found {
+		return val, err
+	}
+	return ParseHexDecOrBin(s)
+}
+
+func tokenize(s string, env Env) ([]tokenValue, error) {
 	// first--eliminate ignored chars
 	// this allow for writing numbers like:
 	// "1,000", "1 000 000", "1_000_000" etc
@@ -481,6 +481,43 @@ func tokenize(s string) ([]tokenValue, error) {
 	numBuf := ""
 	tokens := make([]tokenValue, 0)
 	for i := 0; i < len(s); i++ {
+		if s[i] == '[' {
+			// data read like "[0x3C:u32le]", which can have nested reads in its offset.
+			end := -1
+			depth := 0
+			for j := i + 1; j < len(s) && end < 0; j++ {
+				if s[j] == '[' {
+					depth++
+				} else if s[j] == ']' {
+					if depth == 0 {
+						end = j
+					}
+					depth--
+				}
+			}
+			if end < 0 {
+				return nil, errors.New("no closing ']'")
+			}
+			if len(numBuf) > 0 {
+				val, err := parseNumOrVariable(numBuf, env)
+				if err != nil {
+					return nil, err
+				}
+				tokens = append(tokens, tokenValue{token: Number, value: val})
+				numBuf = ""
+			}
+			val, err := readData(s[i+1:end], env)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tokenValue{token: Number, value: val})
+			i = end
+			continue
+		}
+		if s[i] == ']' {
+			return nil, errors.New("mismatched ']'")
+		}
+
 		var curToken Token
 		switch cur := s[i]; cur {
 		case '(':
@@ -530,7 +567,7 @@ func tokenize(s string) ([]tokenValue, error) {
 
 		if curToken != Number {
 			if len(numBuf) > 0 { // flush any buffered number
-				val, err := ParseHexDecOrBin(numBuf)
+				val, err := parseNumOrVariable(numBuf, env)
 				if err != nil {
 					return nil, err
 				}
@@ -546,7 +583,7 @@ func tokenize(s string) ([]tokenValue, error) {
 	}
 
 	if len(numBuf) > 0 { // flush any buffered number
-		val, err := ParseHexDecOrBin(numBuf)
+		val, err := parseNumOrVariable(numBuf, env)
 		if err != nil {
 			return nil, err
 		}
@@ -1,6 +1,7 @@
 package eval
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 )
@@ -161,7 +162,7 @@ func Test_tokenize(t *testing.T) {
 		}},
 	}
 	for _, c := range cases {
-		val, err := tokenize(c.input)
+		val, err := tokenize(c.input, nil)
 		if !tokenValueSliceEqual(c.expectedVal, val) {
 			t.Errorf("Unexpected value, input: %v, expect: %v, got: %v", c.input, c.expectedVal, val)
 		}
@@ -354,3 +355,67 @@ func Test_Eval_EvalExpression(t *testing.T) {
 		}
 	}
 }
+
+// bytesEnv is an Env of in memory data.
+type bytesEnv struct {
+	*bytes.Reader
+}
+
+func (e bytesEnv) Size() (int64, error) {
+	return e.Reader.Size(), nil
+}
+
+func Test_Eval_EvalExpressionEnv(t *testing.T) {
+	// like a PE file: offset 0x3C points at the "PE" header at 0x10
+	data := []byte("MZ\x00\x00\x10\x00\x00\x00\xFE\xFF\x01\x02\x03\x04\x05\x06PE\x00\x00\x80\x00")
+	type testCase struct {
+		input       string
+		expectedVal int64
+		expectedErr string
+	}
+	cases := []testCase{
+		{input: "size", expectedVal: 22},
+		{input: "SIZE - 0x10", expectedVal: 6},
+		{input: "(size-2)*2", expectedVal: 40},
+		{input: "[0]", expectedVal: 0x4D},
+		{input: "[1:u8]", expectedVal: 0x5A},
+		{input: "[4:u32le]", expectedVal: 0x10},
+		{input: "[4:u32]", expectedVal: 0x10},
+		{input: "[4:u32be]", expectedVal: 0x10000000},
+		{input: "[8:u16le]", expectedVal: 0xFFFE},
+		{input: "[8:i16le]", expectedVal: -2},
+		{input: "[8:i8]", expectedVal: -2},
+		{input: "[0x0A:u64be]", expectedVal: 0x0102030405065045},
+		{input: "[[4:u32le]:u16be]", expectedVal: 0x5045},
+		{input: "[[4:u32le] + 4]", expectedVal: 0x80},
+		{input: "[-2:u16le] + 1", expectedVal: 0x81},
+		{input: "[size-1]", expectedVal: 0},
+		{input: "2*[4:u32le]+[0]", expectedVal: 0x20 + 0x4D},
+
+		{input: "[22]", expectedErr: "Failed to read [22] at offset 0x16, past the end of the data"},
+		{input: "[20:u32le]", expectedErr: "past the end of the data"},
+		{input: "[-30]", expectedErr: "offset is before the start of the data (22 bytes)"},
+		{input: "[0:u24]", expectedErr: "Invalid type \"u24\" in [0:u24]"},
+		{input: "[0:f32]", expectedErr: "Invalid type \"f32\""},
+		{input: "[0", expectedErr: "no closing ']'"},
+		{input: "0]", expectedErr: "mismatched ']'"},
+		{input: "sizes", expectedErr: "invalid syntax"},
+	}
+	env := bytesEnv{bytes.NewReader(data)}
+	for _, c := range cases {
+		val, err := EvalExpressionEnv(c.input, env)
+		if val != c.expectedVal {
+			t.Errorf("Unexpected value, input: %v, expect: %v, got: %v", c.input, c.expectedVal, val)
+		}
+		if !ErrorContains(err, c.expectedErr) {
+			t.Errorf("Unexpected err, input: %v, expect: %v, got: %v", c.input, c.expectedErr, err)
+		}
+	}
+
+	// without any input data
+	for _, input := range []string{"size", "[0x3C:u32le]", "1+[0]"} {
+		if _, err := EvalExpressionEnv(input, nil); !ErrorContains(err, "needs input data") {
+			t.Errorf("Unexpected err, input: %v, expect: needs input data, got: %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package input
+
+import (
+	"io"
+)
+
+// Ascii85DelimiterReader strips the optional "<~" and "~>" that Adobe (ex:
+// PDF and PostScript) puts around ascii85 data. Anything after "~>" is ignored.
+type Ascii85DelimiterReader struct {
+	wrapped io.Reader
+	// whether past any leading whitespace and "<~"
+	started bool
+	// a '<' at the start that may or may not be followed by '~'
+	pendingOpen bool
+	done        bool
+}
+
+func NewAscii85DelimiterReader(reader io.Reader) *Ascii85DelimiterReader {
+	return &Ascii85DelimiterReader{wrapped: reader}
+}
+
+func (r *Ascii85DelimiterReader) Read(p []byte) (int, error) {
+	for !r.done {
+		n, err := r.wrapped.Read(p)
+		out := 0
+		for _, c := range p[:n] {
+			if !r.started {
+				if r.pendingOpen {
+					r.pendingOpen = false
+					r.started = true
+					if c == '~' {
+						continue
+					}
+					// NOTE: '<' is also a valid ascii85 char
+					p[out] = '<'
+					out++
+				} else if c == '<' {
+					r.pendingOpen = true
+					continue
+				} else if c == ' ' || c == '\n' || c == '\r' || c == '\t' {
+					continue
+				} else {
+					r.started = true
+				}
+			}
+			if c == '~' {
+				r.done = true
+				break
+			}
+			p[out] = c
+			out++
+		}
+		if err == io.EOF && r.pendingOpen {
+			r.pendingOpen = false
+			p[out] = '<'
+			out++
+		}
+		if err != nil {
+			r.done = true
+		}
+		if out > 0 || err != nil {
+			if r.done && err == nil {
+				err = io.EOF
+			}
+			return out, err
+		}
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,62 @@
+package input
+
+import (
+	"io"
+)
+
+// Base64NormalizingReader converts any base64 variant into std base64 so a
+// single decoder handles them all. The URL safe alphabet's "-" and "_" become
+// "+" and "/", and missing "=" padding is added at the end of the data.
+// Expects whitespace to already be filtered out.
+type Base64NormalizingReader struct {
+	wrapped io.Reader
+	// Number of base64 chars (including any padding) read so far
+	numChars int64
+	// Padding to add after the wrapped reader is done.
+	padding []byte
+	err     error
+}
+
+func NewBase64NormalizingReader(reader io.Reader) *Base64NormalizingReader {
+	return &Base64NormalizingReader{wrapped: reader}
+}
+
+func (r *Base64NormalizingReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		n := copy(p, r.padding)
+		r.padding = r.padding[n:]
+		if len(r.padding) > 0 {
+			return n, nil
+		}
+		return n, r.err
+	}
+
+	n, err := r.wrapped.Read(p)
+	for i, b := range p[:n] {
+		switch b {
+		case '-':
+			p[i] = '+'
+		case '_':
+			p[i] = '/'
+		}
+	}
+	r.numChars += int64(n)
+	if err == nil {
+		return n, nil
+	}
+
+	r.err = err
+	// NOTE: a remainder of 1 is never valid base64, leave that for the decoder to report.
+	if err == io.EOF && r.numChars%4 > 1 {
+		r.padding = []byte("==")[:4-r.numChars%4]
+	}
+	if len(r.padding) > 0 {
+		added := copy(p[n:], r.padding)
+		r.padding = r.padding[added:]
+		n += added
+		if len(r.padding) > 0 {
+			return n, nil
+		}
+	}
+	return n, err
+}
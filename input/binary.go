@@ -0,0 +1,90 @@
+package input
+
+import (
+	"fmt"
+	"io"
+)
+
+// BinaryDecoder decodes a string of '0' and '1' digits into bytes, 8 digits
+// per byte. "0b" prefixes (ex: "0b01000001") are skipped, so wrap with a
+// FilteringReader to also skip whitespace and separators.
+type BinaryDecoder struct {
+	wrapped io.Reader
+	// first digit of each byte is the least significant bit instead of the most.
+	lsbFirst bool
+	readBuf  []byte
+	// a '0' that could be the start of a "0b" prefix
+	pendingZero bool
+	current     byte
+	numBits     int
+	numDigits   int64
+	out         []byte
+	err         error
+}
+
+func NewBinaryDecoder(reader io.Reader, lsbFirst bool) *BinaryDecoder {
+	return &BinaryDecoder{
+		wrapped:  reader,
+		lsbFirst: lsbFirst,
+		readBuf:  make([]byte, readerBufferSize),
+	}
+}
+
+func (d *BinaryDecoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 && d.err == nil {
+		n, err := d.wrapped.Read(d.readBuf)
+		for _, c := range d.readBuf[:n] {
+			if d.pendingZero {
+				d.pendingZero = false
+				if c == 'b' || c == 'B' {
+					continue // was a "0b" prefix
+				}
+				d.addBit(0)
+			}
+			switch c {
+			case '0':
+				d.pendingZero = true
+			case '1':
+				d.addBit(1)
+			default:
+				d.err = fmt.Errorf("Invalid binary digit %q after %d digits", c, d.numDigits)
+			}
+			if d.err != nil {
+				break
+			}
+		}
+		if d.err == nil && err != nil {
+			if err == io.EOF {
+				if d.pendingZero {
+					d.pendingZero = false
+					d.addBit(0)
+				}
+				if d.numBits != 0 {
+					err = fmt.Errorf("Binary input of %d digits is not a multiple of 8", d.numDigits)
+				}
+			}
+			d.err = err
+		}
+	}
+	if len(d.out) > 0 {
+		n := copy(p, d.out)
+		d.out = d.out[n:]
+		return n, nil
+	}
+	return 0, d.err
+}
+
+func (d *BinaryDecoder) addBit(bit byte) {
+	if d.lsbFirst {
+		d.current |= bit << uint(d.numBits)
+	} else {
+		d.current = d.current<<1 | bit
+	}
+	d.numBits++
+	d.numDigits++
+	if d.numBits == 8 {
+		d.out = append(d.out, d.current)
+		d.current = 0
+		d.numBits = 0
+	}
+}
@@ -0,0 +1,67 @@
+package input
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+
+	"github.com/jcuga/hax/options"
+)
+
+// DetectCompression checks the magic bytes at the start of data for gzip,
+// zlib or bzip2. Raw deflate has no header so is never detected.
+func DetectCompression(start []byte) options.Compression {
+	switch {
+	case len(start) >= 2 && start[0] == 0x1F && start[1] == 0x8B:
+		return options.Gzip
+	case len(start) >= 3 && start[0] == 'B' && start[1] == 'Z' && start[2] == 'h':
+		return options.Bzip2
+	// zlib's first byte is the deflate method (8) and window size, the
+	// first two bytes as a big endian number are a multiple of 31.
+	case len(start) >= 2 && start[0]&0x0F == 8 && start[0]>>4 <= 7 && (uint16(start[0])<<8|uint16(start[1]))%31 == 0:
+		return options.Zlib
+	default:
+		return options.NoCompression
+	}
+}
+
+// NewDecompressReader wraps reader to decompress its data. For
+// options.AutoCompression, data without known magic bytes is left as-is.
+func NewDecompressReader(reader io.Reader, compression options.Compression) (io.Reader, error) {
+	if compression == options.AutoCompression {
+		bufReader := bufio.NewReader(reader)
+		start, err := bufReader.Peek(3)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("Failed to read input to detect compression, error: %v", err)
+		}
+		reader = bufReader
+		compression = DetectCompression(start)
+	}
+
+	switch compression {
+	case options.NoCompression:
+		return reader, nil
+	case options.Gzip:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decompress gzip input, error: %v", err)
+		}
+		return gzipReader, nil
+	case options.Zlib:
+		zlibReader, err := zlib.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decompress zlib input, error: %v", err)
+		}
+		return zlibReader, nil
+	case options.Bzip2:
+		return bzip2.NewReader(reader), nil
+	case options.Deflate:
+		return flate.NewReader(reader), nil
+	default:
+		return nil, fmt.Errorf("Unsupported compression: %v", compression)
+	}
+}
@@ -0,0 +1,76 @@
+package input
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/options"
+)
+
+func Test_GetInput_Decompress(t *testing.T) {
+	data := strings.Repeat("hello compressed world! ", 50)
+	var gzipped, zlibbed, deflated bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte(data))
+	gw.Close()
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write([]byte(data))
+	zw.Close()
+	fw, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	fw.Write([]byte(data))
+	fw.Close()
+	// bzip2 of "hello" since the stdlib can only decompress it
+	bzipped := "BZh91AY&SY\x19\x31\x65\x3d\x00\x00\x00\x81\x00\x02\x44\xa0\x00\x21\x9a\x68\x33\x4d\x07\x33\x8b\xb9\x22\x9c\x28\x48\x0c\x98\xb2\x9e\x80"
+
+	type testCase struct {
+		compression options.Compression
+		inputData   string
+		offset      int64
+		limit       int64
+		expected    string
+		expectedErr string
+	}
+	cases := []testCase{
+		{options.Gzip, gzipped.String(), 0, 0, data, ""},
+		{options.Zlib, zlibbed.String(), 0, 0, data, ""},
+		{options.Deflate, deflated.String(), 0, 0, data, ""},
+		{options.Bzip2, bzipped, 0, 0, "hello", ""},
+		{options.AutoCompression, gzipped.String(), 0, 0, data, ""},
+		{options.AutoCompression, zlibbed.String(), 0, 0, data, ""},
+		{options.AutoCompression, bzipped, 0, 0, "hello", ""},
+		{options.AutoCompression, "not compressed", 0, 0, "not compressed", ""},
+		{options.Gzip, gzipped.String(), 6, 10, data[6:16], ""},
+		{options.Gzip, "not compressed", 0, 0, "", "Failed to decompress gzip input, error: gzip: invalid header"},
+	}
+	for _, c := range cases {
+		opts := options.Options{InputMode: options.Raw, InputData: c.inputData, Decompress: c.compression, Offset: c.offset}
+		reader, _, _, err := GetInput(&opts)
+		if c.expectedErr != "" {
+			if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("compression: %v, expected err: %q, got: %v", c.compression, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("compression: %v, unexpected err: %v", c.compression, err)
+		}
+		limit := c.limit
+		if limit == 0 {
+			limit = math.MaxInt64
+		}
+		result, err := ioutil.ReadAll(io.LimitReader(reader, limit))
+		if err != nil {
+			t.Errorf("compression: %v, unexpected read err: %v", c.compression, err)
+		}
+		if string(result) != c.expected {
+			t.Errorf("compression: %v, expected: %q, got: %q", c.compression, c.expected, result)
+		}
+	}
+}
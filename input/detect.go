@@ -0,0 +1,176 @@
+package input
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/jcuga/hax/options"
+)
+
+// Amount of data looked at by DetectInputMode.
+const detectSampleSize = 4096
+
+var (
+	intelHexLineRegex  = regexp.MustCompile(`^:[0-9A-Fa-f]+$`)
+	sRecordLineRegex   = regexp.MustCompile(`^S[0-9][0-9A-Fa-f]+$`)
+	xxdRowRegex        = regexp.MustCompile(`^\s*[0-9a-fA-F]+: [0-9a-fA-F]{4}( |$)`)
+	displayRowRegex    = regexp.MustCompile(`^\s*[0-9a-fA-F]+: ([0-9A-Fa-f]{2}|  )( |$)`)
+	hexdumpRowRegex    = regexp.MustCompile(`^[0-9a-fA-F]{8}  [0-9a-fA-F]{2} `)
+	odRowRegex         = regexp.MustCompile(`^[0-7]{7} [0-7]{6}( |$)`)
+	hexStringRegex     = regexp.MustCompile(`^(\s*\\x[0-9a-fA-F]{2})+\s*$`)
+	hexListRegex       = regexp.MustCompile(`^\s*(0[xX][0-9a-fA-F]{2}\s*,?\s*)+$`)
+	binaryPrefixRegex  = regexp.MustCompile(`0[bB]`)
+	binaryDigitsRegex  = regexp.MustCompile(`^[01\s,_]+$`)
+	hexDigitsRegex     = regexp.MustCompile(`^[0-9a-fA-F\s]+$`)
+	base64Regex        = regexp.MustCompile(`^[A-Za-z0-9+/\-_\r\n]+=*[\r\n]*$`)
+	base64LettersRegex = regexp.MustCompile(`^[A-Za-z]+$`)
+)
+
+// DetectInputMode guesses the input mode from a sample of the start of the
+// data. complete is whether the sample is all of the data, otherwise checks
+// on the total length (ex: even number of hex digits) are skipped. Also
+// returns any other modes the data could reasonably be, ex: "abcd" is hex
+// but could be base64.
+func DetectInputMode(sample []byte, complete bool) (options.IOMode, []options.IOMode) {
+	text := ansiEscapeRegex.ReplaceAll(sample, nil)
+	for _, b := range text {
+		if (b < 0x20 && b != '\t' && b != '\r' && b != '\n') || b >= 0x7F {
+			return options.Raw, nil
+		}
+	}
+	if !complete {
+		// don't judge a partial last line
+		if end := bytes.LastIndexByte(text, '\n'); end > 0 {
+			text = text[:end]
+		}
+	}
+	trimmed := strings.TrimSpace(string(text))
+	if trimmed == "" {
+		return options.Raw, nil
+	}
+	lines := strings.Split(trimmed, "\n")
+
+	if mode, found := detectRecords(lines); found {
+		return mode, nil
+	}
+	if mode, found := detectDump(lines); found {
+		return mode, nil
+	}
+
+	switch {
+	case hexStringRegex.MatchString(trimmed):
+		return options.HexString, nil
+	case hexListRegex.MatchString(trimmed):
+		return options.HexList, nil
+	}
+
+	isHex := hexDigitsRegex.MatchString(trimmed)
+	if isHex && complete {
+		isHex = len(strings.Join(strings.Fields(trimmed), ""))%2 == 0
+	}
+	// base64 is wrapped by lines, never has spaces
+	isBase64 := base64Regex.MatchString(trimmed)
+	if isBase64 && complete {
+		isBase64 = len(strings.Join(strings.Fields(trimmed), ""))%4 != 1
+	}
+
+	binaryDigits := binaryPrefixRegex.ReplaceAllString(trimmed, "")
+	if binaryDigitsRegex.MatchString(binaryDigits) {
+		numDigits := len(strings.Map(func(r rune) rune {
+			if r == '0' || r == '1' {
+				return r
+			}
+			return -1
+		}, binaryDigits))
+		if !complete || numDigits%8 == 0 {
+			if isHex && !strings.Contains(trimmed, "0b") && !strings.Contains(trimmed, "0B") {
+				return options.Binary, []options.IOMode{options.Hex}
+			}
+			return options.Binary, nil
+		}
+	}
+
+	if isHex {
+		if isBase64 {
+			return options.Hex, []options.IOMode{options.Base64}
+		}
+		return options.Hex, nil
+	}
+	if isBase64 {
+		mode := options.Base64
+		if strings.ContainsAny(trimmed, "-_") {
+			mode = options.Base64Url
+		}
+		joined := strings.Join(strings.Fields(trimmed), "")
+		if !hasBase64Evidence(joined, complete) {
+			// plain words are valid base64 too, but are much more likely text
+			return options.Raw, []options.IOMode{mode}
+		}
+		if base64LettersRegex.MatchString(joined) {
+			return mode, []options.IOMode{options.Raw}
+		}
+		return mode, nil
+	}
+	return options.Raw, nil
+}
+
+// hasBase64Evidence is whether data that is valid base64 looks like it
+// actually is base64 rather than text: it has '=' padding, digits or
+// symbols mixed in with letters, or is a complete multiple of 4 chars in
+// mixed case.
+func hasBase64Evidence(joined string, complete bool) bool {
+	if strings.HasSuffix(joined, "=") {
+		return true
+	}
+	if !base64LettersRegex.MatchString(joined) {
+		return strings.IndexFunc(joined, func(r rune) bool {
+			return (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z')
+		}) >= 0
+	}
+	return complete && len(joined)%4 == 0 &&
+		strings.ToLower(joined) != joined && strings.ToUpper(joined) != joined
+}
+
+// detectRecords checks if every line is an Intel HEX or S-record record.
+func detectRecords(lines []string) (options.IOMode, bool) {
+	for _, check := range []struct {
+		mode  options.IOMode
+		regex *regexp.Regexp
+	}{{options.IntelHex, intelHexLineRegex}, {options.SRecord, sRecordLineRegex}} {
+		matches := true
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line != "" && !check.regex.MatchString(line) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return check.mode, true
+		}
+	}
+	return options.Raw, false
+}
+
+// detectDump checks the first few lines for a row of xxd, hexdump -C, od or
+// hax display output. Display output starts with a column header line.
+func detectDump(lines []string) (options.IOMode, bool) {
+	for i, line := range lines {
+		if i >= 3 {
+			break
+		}
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case hexdumpRowRegex.MatchString(line):
+			return options.HexdumpCanonical, true
+		case xxdRowRegex.MatchString(line):
+			return options.Xxd, true
+		case displayRowRegex.MatchString(line):
+			return options.Display, true
+		case odRowRegex.MatchString(line):
+			return options.Od, true
+		}
+	}
+	return options.Raw, false
+}
@@ -0,0 +1,54 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jcuga/hax/options"
+)
+
+func Test_DetectInputMode(t *testing.T) {
+	type testCase struct {
+		sample       string
+		complete     bool
+		expected     options.IOMode
+		alternatives []options.IOMode
+	}
+	cases := []testCase{
+		{"", true, options.Raw, nil},
+		{"hello\x00world", true, options.Raw, nil},
+		{"hello world", true, options.Raw, nil},
+		{"caf\xC3\xA9", true, options.Raw, nil},
+		{"48 65 6c 6c 6f", true, options.Hex, nil},
+		{"48656c6c", true, options.Hex, []options.IOMode{options.Base64}},
+		{"48656c6c6", false, options.Hex, []options.IOMode{options.Base64}},
+		{"\\x48\\x65\n\\x6C", true, options.HexString, nil},
+		{"0x48, 0x65,\n0x6C", true, options.HexList, nil},
+		{"01000001 01000010", true, options.Binary, []options.IOMode{options.Hex}},
+		{"0b01000001, 0b0100_0010", true, options.Binary, nil},
+		{"0100", true, options.Hex, []options.IOMode{options.Base64}},
+		{"aGVsbG8gd29ybGQ=", true, options.Base64, nil},
+		{"aGVsbG8g\nd29ybGQ=\n", true, options.Base64, nil},
+		{"-_-_aGk", true, options.Base64Url, nil},
+		{"test", true, options.Raw, []options.IOMode{options.Base64}},
+		{"Hello\nWorld\n", true, options.Raw, []options.IOMode{options.Base64}},
+		{"Hello\nthere\nWorld", false, options.Raw, []options.IOMode{options.Base64}},
+		{"TVqQ", true, options.Base64, []options.IOMode{options.Raw}},
+		{"SGVsbG8", true, options.Base64, nil},
+		{"YQ==", true, options.Base64, nil},
+		{":0100000042BD\n:00000001FF\n", true, options.IntelHex, nil},
+		{"S1050010AABB85\nS9030000FC", true, options.SRecord, nil},
+		{"00000000: 4865 6c6c 6f0a                           hello.", true, options.Xxd, nil},
+		{"00000000  68 65 6c 6c 6f 0a                                 |hello.|\n00000006", true, options.HexdumpCanonical, nil},
+		{"0000000 062510 066154 005157\n0000006", true, options.Od, nil},
+		{"                0  1  2  3\n            0: 48 65 6C 6C\n                H  e  l  l", true, options.Display, nil},
+		{"\x1b[36m            0: \x1b[0m48 65 6C 6C", true, options.Display, nil},
+	}
+	for _, c := range cases {
+		mode, alternatives := DetectInputMode([]byte(c.sample), c.complete)
+		if mode != c.expected || !reflect.DeepEqual(alternatives, c.alternatives) {
+			t.Errorf("sample: %q, expected: %v %v, got: %v %v", c.sample, options.IOModeToString(c.expected), c.alternatives,
+				options.IOModeToString(mode), alternatives)
+		}
+	}
+}
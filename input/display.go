@@ -0,0 +1,239 @@
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ex: "           20: 61 62 63" with the offset in hex.
+	displayDataRowRegex = regexp.MustCompile(`^\s*([0-9A-Fa-f]+):`)
+	// ex: "           40-7F omitted. [64 bytes/4 lines of all zeros]"
+	displayOmittedRegex = regexp.MustCompile(`^\s*([0-9A-Fa-f]+)-([0-9A-Fa-f]+) omitted\.`)
+	// color codes from --pretty output
+	ansiEscapeRegex = regexp.MustCompile("\033\\[[0-9;]*m")
+)
+
+const maxDisplayWidth = 1024
+
+// displayRow is a single row of hex bytes, or an omitted range of zeros.
+type displayRow struct {
+	offset int64
+	// byte values by column, -1 for blank (hidden zero or no data)
+	cells   []int
+	omitted bool
+	lineNum int
+}
+
+// DisplayReader parses output of the display output mode (hex editor style
+// rows of offset, hex and ascii) back into the bytes displayed.
+// Column header rows give where each column is, which is needed for
+// --sub-width and --hide-zeros output; without one the default layout is assumed.
+// Blanks (--hide-zeros) are zeros except at the very start of the first row
+// and end of the last row where they can't be told apart from offset padding.
+// Rows omitted as all zeros are filled back in. Output starts at the first
+// byte displayed, so offsets are relative to that.
+type DisplayReader struct {
+	scanner *bufio.Scanner
+	lineNum int
+	// start of each column's 2 char cell relative to the start of the row's hex.
+	columns     []int
+	haveHeader  bool
+	prevWasData bool
+	// held back until the next row's offset says how long it is.
+	pending    *displayRow
+	nextOffset int64 // next offset to output, -1 before any output
+	out        []byte
+	err        error
+}
+
+func NewDisplayReader(reader io.Reader) *DisplayReader {
+	columns := make([]int, maxDisplayWidth)
+	for i := range columns {
+		columns[i] = i * 3
+	}
+	return &DisplayReader{
+		scanner:    bufio.NewScanner(reader),
+		columns:    columns,
+		nextOffset: -1,
+	}
+}
+
+func (r *DisplayReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 && r.err == nil {
+		r.err = r.parseNextLine()
+	}
+	if len(r.out) > 0 {
+		n := copy(p, r.out)
+		r.out = r.out[n:]
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// parseNextLine parses one more line of input, returning io.EOF when done.
+func (r *DisplayReader) parseNextLine() error {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return err
+		}
+		if r.pending != nil {
+			if err := r.outputRow(*r.pending, nil); err != nil {
+				return err
+			}
+			r.pending = nil
+		}
+		return io.EOF
+	}
+	r.lineNum++
+	line := strings.TrimRight(ansiEscapeRegex.ReplaceAllString(r.scanner.Text(), ""), " \t\r")
+	prevWasData := r.prevWasData
+	r.prevWasData = false
+
+	var row displayRow
+	if parts := displayOmittedRegex.FindStringSubmatch(line); parts != nil {
+		start, startErr := strconv.ParseInt(parts[1], 16, 64)
+		end, endErr := strconv.ParseInt(parts[2], 16, 64)
+		if startErr != nil || endErr != nil || end < start {
+			return fmt.Errorf("Invalid omitted range on line %d: %q", r.lineNum, line)
+		}
+		row = displayRow{offset: start, cells: make([]int, end-start+1), omitted: true, lineNum: r.lineNum}
+	} else if loc := displayDataRowRegex.FindStringSubmatchIndex(line); loc != nil {
+		offset, err := strconv.ParseInt(line[loc[2]:loc[3]], 16, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid offset on line %d: %q", r.lineNum, line)
+		}
+		cells, err := r.parseCells(line, loc[1]+1)
+		if err != nil {
+			return err
+		}
+		row = displayRow{offset: offset, cells: cells, lineNum: r.lineNum}
+		r.prevWasData = true
+	} else {
+		// the line after a row of hex is its ascii, which could look like anything
+		if !prevWasData {
+			r.parseHeader(line)
+		}
+		return nil
+	}
+
+	if r.pending != nil {
+		if err := r.outputRow(*r.pending, &row); err != nil {
+			return err
+		}
+	}
+	r.pending = &row
+	return nil
+}
+
+// parseHeader takes column positions from a column header row like
+// "                0  1  2  3 ..." and ignores any other line.
+func (r *DisplayReader) parseHeader(line string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || len(fields) > maxDisplayWidth {
+		return
+	}
+	columns := make([]int, 0, len(fields))
+	pos := 0
+	firstEnd := 0
+	for i, field := range fields {
+		if val, err := strconv.ParseInt(field, 16, 64); err != nil || val != int64(i) {
+			return
+		}
+		pos += strings.Index(line[pos:], field) + len(field)
+		// labels are right aligned within each 2 char cell
+		if i == 0 {
+			firstEnd = pos
+		}
+		columns = append(columns, pos-firstEnd)
+	}
+	r.columns = columns
+	r.haveHeader = true
+}
+
+// parseCells gets the byte in each column of a data row where rowStart is the
+// index of the space after the offset's ':'.
+func (r *DisplayReader) parseCells(line string, rowStart int) ([]int, error) {
+	cells := make([]int, 0, len(r.columns))
+	used := rowStart
+	for _, column := range r.columns {
+		start := rowStart + column
+		if start >= len(line) {
+			break
+		}
+		if strings.TrimSpace(line[used:start]) != "" {
+			return nil, fmt.Errorf("Unexpected data in display row on line %d: %q", r.lineNum, line[used:start])
+		}
+		end := start + 2
+		if end > len(line) {
+			end = len(line)
+		}
+		cell := line[start:end]
+		used = end
+		if strings.TrimSpace(cell) == "" {
+			cells = append(cells, -1)
+			continue
+		}
+		val, err := strconv.ParseUint(cell, 16, 8)
+		if err != nil || len(cell) != 2 {
+			return nil, fmt.Errorf("Invalid hex byte on line %d: %q", r.lineNum, cell)
+		}
+		cells = append(cells, int(val))
+	}
+	if used < len(line) && strings.TrimSpace(line[used:]) != "" {
+		return nil, fmt.Errorf("Unexpected data in display row on line %d: %q", r.lineNum, line[used:])
+	}
+	return cells, nil
+}
+
+// outputRow adds row's bytes to r.out. The next row (nil if none) determines
+// how many bytes row has.
+func (r *DisplayReader) outputRow(row displayRow, next *displayRow) error {
+	isBlank := func(i int) bool {
+		return i >= len(row.cells) || row.cells[i] < 0
+	}
+	last := len(row.cells)
+	if next != nil {
+		last = int(next.offset - row.offset)
+		maxLen := len(row.cells)
+		if !row.omitted {
+			maxLen = len(r.columns)
+		}
+		// without a header row, the width isn't known so can't tell if rows are missing
+		checkWidth := row.omitted || r.haveHeader
+		if last < 0 || (checkWidth && last > maxLen) {
+			return fmt.Errorf("Display rows out of order or missing between offsets %X and %X, line %d",
+				row.offset, next.offset, next.lineNum)
+		}
+	} else if !row.omitted {
+		for last > 0 && isBlank(last-1) {
+			last-- // nothing after the last byte
+		}
+	}
+	first := 0
+	if !row.omitted && r.nextOffset < 0 {
+		for first < last && isBlank(first) {
+			first++ // offset padding
+		}
+	}
+	if first == last {
+		return nil
+	}
+	if r.nextOffset >= 0 && row.offset+int64(first) != r.nextOffset {
+		return fmt.Errorf("Display rows out of order or missing between offsets %X and %X, line %d",
+			r.nextOffset, row.offset+int64(first), row.lineNum)
+	}
+	for i := first; i < last; i++ {
+		if isBlank(i) {
+			r.out = append(r.out, 0) // hidden zero
+		} else {
+			r.out = append(r.out, byte(row.cells[i]))
+		}
+	}
+	r.nextOffset = row.offset + int64(last)
+	return nil
+}
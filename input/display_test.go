@@ -0,0 +1,125 @@
+package input
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_DisplayReader(t *testing.T) {
+	type testCase struct {
+		name        string
+		text        string
+		expected    string
+		expectedErr string
+	}
+	cases := []testCase{
+		{
+			"no header",
+			"            0: 48 65 6C 6C 6F 2C 20 77 6F 72 6C 64 21 00 00 00 \n" +
+				"                H  e  l  l  o  ,     w  o  r  l  d  !          \n" +
+				"           10: 41 42 \n" +
+				"                A  B \n",
+			"Hello, world!\x00\x00\x00AB", "",
+		},
+		{
+			// without a header the width comes from the offsets
+			"no header width 7",
+			"            0: 48 65 6C 6C 6F 2C 20\n" +
+				"            7: 77 6F 72 6C 64 21 00\n" +
+				"            E: 00 00 41\n",
+			"Hello, world!\x00\x00\x00A", "",
+		},
+		{
+			"width 7",
+			"\n                0  1  2  3  4  5  6 \n" +
+				"            0: 48 65 6C 6C 6F 2C 20 \n" +
+				"                H  e  l  l  o  ,    \n" +
+				"            7: 77 6F 72 6C 64 21 00 \n" +
+				"                w  o  r  l  d  !    \n" +
+				"            E: 00 00 41 42 43 \n" +
+				"                      A  B  C \n",
+			"Hello, world!\x00\x00\x00ABC", "",
+		},
+		{
+			"not starting at 0",
+			"           28:          61 62 63\n" +
+				"           2E: 64\n",
+			"abcd", "",
+		},
+		{
+			"omit-zeros",
+			"            0: 41 42 00 00\n" +
+				"            4-B omitted. [8 bytes/2 lines of all zeros]\n" +
+				"\n" +
+				"            C: 00 43\n",
+			"AB" + strings.Repeat("\x00", 11) + "C", "",
+		},
+		{
+			"omit-zeros at the end",
+			"            0: 41 42 00 00\n" +
+				"            4-7 omitted. [4 bytes/1 lines of all zeros]\n",
+			"AB" + strings.Repeat("\x00", 6), "",
+		},
+		{
+			"sub-width",
+			"                0  1    2  3\n" +
+				"            0: 61 62   63 64\n" +
+				"                a  b    c  d\n" +
+				"            4: 65\n",
+			"abcde", "",
+		},
+		{
+			"colored",
+			"\x1b[1m                0  1  2  3\x1b[0m\n" +
+				"\x1b[36m            0: \x1b[0m48 \x1b[1;31m65\x1b[0m 6C 6C \n" +
+				"               \x1b[32m H\x1b[0m \x1b[32m e\x1b[0m \x1b[32m l\x1b[0m \x1b[32m l\x1b[0m \n" +
+				"\x1b[36m            4: \x1b[0m6F \n",
+			"Hello", "",
+		},
+		{
+			"crlf and lowercase",
+			"            0: de ad\r\n            2: be ef\r\n",
+			"\xDE\xAD\xBE\xEF", "",
+		},
+		{"empty", "", "", ""},
+		// errors:
+		{"invalid byte", "            0: 01 02 0x", "", "Invalid hex byte on line 1: \"0x\""},
+		{"short byte", "            0: 01 2  03", "", "Invalid hex byte on line 1: \"2 \""},
+		{
+			"past header width",
+			"                0  1\n            0: 01 02 03",
+			"", "Unexpected data in display row on line 2: \" 03\"",
+		},
+		{
+			"out of order",
+			"           10: 01 02 03\n            0: 04",
+			"", "Display rows out of order or missing between offsets 10 and 0, line 2",
+		},
+		{
+			"missing row",
+			"                0  1\n            0: 01 02\n            4: 05",
+			"", "Display rows out of order or missing between offsets 0 and 4, line 3",
+		},
+		{
+			"invalid omitted range",
+			"            0: 01 02\n            8-2 omitted. [0 bytes]",
+			"", "Invalid omitted range on line 2: \"            8-2 omitted. [0 bytes]\"",
+		},
+	}
+	for _, c := range cases {
+		result, err := ioutil.ReadAll(NewDisplayReader(strings.NewReader(c.text)))
+		if c.expectedErr != "" {
+			if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("%s: expected err: %q, got: %v", c.name, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected err: %v", c.name, err)
+		}
+		if string(result) != c.expected {
+			t.Errorf("%s: unexpected result, expected: %q, got: %q", c.name, c.expected, result)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package input
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type dumpLineKind int
+
+const (
+	dumpIgnore dumpLineKind = iota
+	dumpRow
+	// "*" meaning the previous row repeats until the next offset
+	dumpRepeat
+	// just the offset after the last byte
+	dumpEnd
+)
+
+type dumpLine struct {
+	kind   dumpLineKind
+	offset int64
+	data   []byte
+}
+
+// DumpReader parses the output of the classic dump tools (xxd, hexdump -C,
+// od) back into bytes. Like xxd -r, bytes are placed at the offsets given
+// in the dump, so a dump that starts past zero gives leading zeros and any
+// other gaps are zero filled, except after a "*" line which repeats the
+// previous row.
+type DumpReader struct {
+	scanner   *bufio.Scanner
+	parseLine func(line string) (dumpLine, error)
+	lineNum   int
+	// offset after the last byte output or held
+	nextOffset int64
+	// the last row is held back as an end offset can cut it short, ex: od pads odd lengths.
+	held      []byte
+	prevRow   []byte
+	repeating bool
+	// contiguous is whether rows must follow on from the previous row unless
+	// after a "*" line, and the end offset can only cut off a padding byte.
+	// Set for od whose offsets are otherwise ambiguous.
+	contiguous bool
+	ended      bool
+	out        []byte
+	err        error
+}
+
+func newDumpReader(reader io.Reader, parseLine func(line string) (dumpLine, error)) *DumpReader {
+	return &DumpReader{
+		scanner:   bufio.NewScanner(reader),
+		parseLine: parseLine,
+	}
+}
+
+// NewXxdReader parses xxd output, ex: "00000010: 6865 6c6c 6f0a  hello.",
+// including "*" lines from xxd -a.
+func NewXxdReader(reader io.Reader) *DumpReader {
+	return newDumpReader(reader, parseXxdLine)
+}
+
+// NewHexdumpCanonicalReader parses hexdump -C (or hd) output, ex:
+// "00000010  68 65 6c 6c 6f 0a              |hello.|"
+func NewHexdumpCanonicalReader(reader io.Reader) *DumpReader {
+	return newDumpReader(reader, parseHexdumpCanonicalLine)
+}
+
+// NewOdReader parses od output. Supports the default of octal offsets and
+// octal words (assumed little endian) as well as -b (octal bytes), -t x1 and
+// -A x -t x1z. Offsets are octal unless 6 digits, which is od -A x.
+// Other formats like -t u1 or -A d are errors rather than being misread.
+func NewOdReader(reader io.Reader) *DumpReader {
+	valueLen := 0
+	r := newDumpReader(reader, func(line string) (dumpLine, error) {
+		return parseOdLine(line, &valueLen)
+	})
+	r.contiguous = true
+	return r
+}
+
+func (r *DumpReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 && r.err == nil {
+		r.err = r.parseNextLine()
+	}
+	if len(r.out) > 0 {
+		n := copy(p, r.out)
+		r.out = r.out[n:]
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// parseNextLine parses one more line of input, returning io.EOF when done.
+func (r *DumpReader) parseNextLine() error {
+	if r.ended || !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return err
+		}
+		r.out = append(r.out, r.held...)
+		r.held = nil
+		return io.EOF
+	}
+	r.lineNum++
+	line, err := r.parseLine(strings.TrimRight(r.scanner.Text(), "\r"))
+	if err != nil {
+		return fmt.Errorf("Invalid dump on line %d: %v", r.lineNum, err)
+	}
+	switch line.kind {
+	case dumpRepeat:
+		r.repeating = true
+	case dumpRow:
+		if r.contiguous && r.prevRow != nil && !r.repeating && line.offset != r.nextOffset {
+			return fmt.Errorf("Invalid dump on line %d: offset %X doesn't follow on from the previous row ending at %X",
+				r.lineNum, line.offset, r.nextOffset)
+		}
+		if err := r.fillTo(line.offset); err != nil {
+			return err
+		}
+		r.out = append(r.out, r.held...)
+		r.held = line.data
+		r.prevRow = line.data
+		r.nextOffset = line.offset + int64(len(line.data))
+	case dumpEnd:
+		if r.contiguous && (line.offset < r.nextOffset-1 || (line.offset > r.nextOffset && !r.repeating)) {
+			return fmt.Errorf("Invalid dump on line %d: end offset %X doesn't match the data ending at %X",
+				r.lineNum, line.offset, r.nextOffset)
+		}
+		if line.offset < r.nextOffset {
+			cut := r.nextOffset - line.offset
+			if cut > int64(len(r.held)) {
+				return fmt.Errorf("Invalid dump on line %d: end offset %X is before data", r.lineNum, line.offset)
+			}
+			r.held = r.held[:int64(len(r.held))-cut]
+			r.nextOffset = line.offset
+		} else if err := r.fillTo(line.offset); err != nil {
+			return err
+		}
+		r.ended = true
+	}
+	return nil
+}
+
+// fillTo fills any gap before offset with either repeats of the previous row
+// (after a "*") or zeros.
+func (r *DumpReader) fillTo(offset int64) error {
+	if offset < r.nextOffset {
+		return fmt.Errorf("Invalid dump on line %d: offset %X is before previous data ending at %X",
+			r.lineNum, offset, r.nextOffset)
+	}
+	if offset == r.nextOffset {
+		r.repeating = false
+		return nil
+	}
+	r.out = append(r.out, r.held...)
+	r.held = nil
+	for i := int64(0); r.nextOffset < offset; i++ {
+		if r.repeating && len(r.prevRow) > 0 {
+			r.out = append(r.out, r.prevRow[i%int64(len(r.prevRow))])
+		} else {
+			r.out = append(r.out, 0)
+		}
+		r.nextOffset++
+	}
+	r.repeating = false
+	return nil
+}
+
+var (
+	xxdLineRegex     = regexp.MustCompile(`^\s*([0-9a-fA-F]+):\s*(.*)$`)
+	dumpOffsetRegex  = regexp.MustCompile(`^\s*([0-9a-fA-F]+)$`)
+	hexdumpLineRegex = regexp.MustCompile(`^\s*([0-9a-fA-F]+)\s+(.*)$`)
+)
+
+func parseXxdLine(line string) (dumpLine, error) {
+	if strings.TrimSpace(line) == "" {
+		return dumpLine{kind: dumpIgnore}, nil
+	}
+	if strings.TrimSpace(line) == "*" {
+		return dumpLine{kind: dumpRepeat}, nil
+	}
+	parts := xxdLineRegex.FindStringSubmatch(line)
+	if parts == nil {
+		return dumpLine{}, fmt.Errorf("%q", line)
+	}
+	offset, err := strconv.ParseInt(parts[1], 16, 64)
+	if err != nil {
+		return dumpLine{}, fmt.Errorf("invalid offset: %q", parts[1])
+	}
+	// the ascii column comes after 2 spaces
+	hexPart := parts[2]
+	if i := strings.Index(hexPart, "  "); i >= 0 {
+		hexPart = hexPart[:i]
+	}
+	data, err := hex.DecodeString(strings.Replace(hexPart, " ", "", -1))
+	if err != nil {
+		return dumpLine{}, fmt.Errorf("invalid hex: %q", hexPart)
+	}
+	return dumpLine{kind: dumpRow, offset: offset, data: data}, nil
+}
+
+func parseHexdumpCanonicalLine(line string) (dumpLine, error) {
+	if strings.TrimSpace(line) == "" {
+		return dumpLine{kind: dumpIgnore}, nil
+	}
+	if strings.TrimSpace(line) == "*" {
+		return dumpLine{kind: dumpRepeat}, nil
+	}
+	if parts := dumpOffsetRegex.FindStringSubmatch(line); parts != nil {
+		offset, err := strconv.ParseInt(parts[1], 16, 64)
+		if err != nil {
+			return dumpLine{}, fmt.Errorf("invalid offset: %q", parts[1])
+		}
+		return dumpLine{kind: dumpEnd, offset: offset}, nil
+	}
+	parts := hexdumpLineRegex.FindStringSubmatch(line)
+	if parts == nil {
+		return dumpLine{}, fmt.Errorf("%q", line)
+	}
+	offset, err := strconv.ParseInt(parts[1], 16, 64)
+	if err != nil {
+		return dumpLine{}, fmt.Errorf("invalid offset: %q", parts[1])
+	}
+	hexPart := parts[2]
+	if i := strings.Index(hexPart, "|"); i >= 0 {
+		hexPart = hexPart[:i]
+	}
+	var data []byte
+	for _, field := range strings.Fields(hexPart) {
+		val, err := strconv.ParseUint(field, 16, 8)
+		if err != nil || len(field) != 2 {
+			return dumpLine{}, fmt.Errorf("invalid hex byte: %q", field)
+		}
+		data = append(data, byte(val))
+	}
+	return dumpLine{kind: dumpRow, offset: offset, data: data}, nil
+}
+
+var (
+	// default octal offsets, or 6 hex digits for -A x
+	odOffsetRegex    = regexp.MustCompile(`^([0-7]{7,}|[0-9a-fA-F]{6})$`)
+	odOctalWordRegex = regexp.MustCompile(`^[0-1][0-7]{5}$`)
+	odOctalByteRegex = regexp.MustCompile(`^[0-3][0-7]{2}$`)
+	odHexByteRegex   = regexp.MustCompile(`^[0-9a-fA-F]{2}$`)
+)
+
+const odFormatsError = "unsupported od format, expect the default, -b, -t x1 or -t x1z"
+
+// parseOdLine parses a line of od output. Values are separated by single
+// spaces and all have the same width, which is kept in valueLen so every row
+// has to use the first row's format.
+func parseOdLine(line string, valueLen *int) (dumpLine, error) {
+	// drop the ascii of -t x1z, ex: ">hello<"
+	if i := strings.Index(line, "  >"); i >= 0 {
+		line = line[:i]
+	}
+	line = strings.TrimRight(line, " \t")
+	if strings.TrimSpace(line) == "" {
+		return dumpLine{kind: dumpIgnore}, nil
+	}
+	if strings.TrimSpace(line) == "*" {
+		return dumpLine{kind: dumpRepeat}, nil
+	}
+	fields := strings.Split(line, " ")
+	if !odOffsetRegex.MatchString(fields[0]) {
+		return dumpLine{}, fmt.Errorf("invalid offset: %q, expect od's default octal offsets or -A x", fields[0])
+	}
+	offsetBase := 8
+	if len(fields[0]) == 6 {
+		offsetBase = 16 // od -A x
+	}
+	offset, err := strconv.ParseInt(fields[0], offsetBase, 64)
+	if err != nil {
+		return dumpLine{}, fmt.Errorf("invalid offset: %q", fields[0])
+	}
+	if len(fields) == 1 {
+		return dumpLine{kind: dumpEnd, offset: offset}, nil
+	}
+	if *valueLen == 0 {
+		*valueLen = len(fields[1])
+	}
+	var data []byte
+	for _, field := range fields[1:] {
+		if len(field) != *valueLen {
+			return dumpLine{}, errors.New(odFormatsError)
+		}
+		var val uint64
+		switch {
+		case len(field) == 6 && odOctalWordRegex.MatchString(field): // default, octal little endian 2 byte word
+			val, _ = strconv.ParseUint(field, 8, 16)
+			data = append(data, byte(val), byte(val>>8))
+		case len(field) == 3 && odOctalByteRegex.MatchString(field): // -b, octal byte
+			val, _ = strconv.ParseUint(field, 8, 8)
+			data = append(data, byte(val))
+		case len(field) == 2 && odHexByteRegex.MatchString(field): // -t x1, hex byte
+			val, _ = strconv.ParseUint(field, 16, 8)
+			data = append(data, byte(val))
+		default:
+			return dumpLine{}, fmt.Errorf("invalid value: %q, %s", field, odFormatsError)
+		}
+	}
+	return dumpLine{kind: dumpRow, offset: offset, data: data}, nil
+}
@@ -0,0 +1,103 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/jcuga/hax/options"
+)
+
+// ExprEnv gives expressions in --offset, --limit, --range and calc the
+// input's size and data, see eval.Env. The input is only read if an
+// expression uses them. A raw file is read from directly, otherwise the
+// data is decoded into memory.
+type ExprEnv struct {
+	rawOpts  options.RawOptions
+	loaded   bool
+	size     int64
+	readerAt io.ReaderAt
+	closer   io.Closer
+	err      error
+}
+
+func NewExprEnv(rawOpts options.RawOptions) *ExprEnv {
+	// expressions are of all of the data, not just the selected part of it.
+	rawOpts.Offset, rawOpts.Limit, rawOpts.Ranges = "", "", nil
+	rawOpts.ExprEnv = nil
+	return &ExprEnv{rawOpts: rawOpts}
+}
+
+func (e *ExprEnv) load() error {
+	if e.loaded {
+		return e.err
+	}
+	e.loaded = true
+	if len(e.rawOpts.InputData) == 0 && len(e.rawOpts.Filename) == 0 {
+		// reading stdin here would leave nothing for the actual output.
+		e.err = errors.New("Expressions using the input's size or data need --file or --str input, not stdin")
+		return e.err
+	}
+	opts, err := options.New(e.rawOpts)
+	if err != nil {
+		e.err = err
+		return e.err
+	}
+
+	if len(opts.InputData) == 0 && opts.InputMode == options.Raw && opts.Decompress == options.NoCompression {
+		f, err := os.Open(opts.Filename)
+		if err != nil {
+			e.err = err
+			return e.err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			e.err = err
+			return e.err
+		}
+		e.size, e.readerAt, e.closer = info.Size(), f, f
+		return nil
+	}
+
+	reader, closer, _, err := GetInput(&opts)
+	if err != nil {
+		e.err = err
+		return e.err
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		e.err = fmt.Errorf("Error reading data: %v", err)
+		return e.err
+	}
+	e.size, e.readerAt = int64(len(data)), bytes.NewReader(data)
+	return nil
+}
+
+func (e *ExprEnv) Size() (int64, error) {
+	if err := e.load(); err != nil {
+		return 0, err
+	}
+	return e.size, nil
+}
+
+func (e *ExprEnv) ReadAt(p []byte, offset int64) (int, error) {
+	if err := e.load(); err != nil {
+		return 0, err
+	}
+	return e.readerAt.ReadAt(p, offset)
+}
+
+// Close closes the input file if one was opened.
+func (e *ExprEnv) Close() error {
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
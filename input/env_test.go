@@ -0,0 +1,61 @@
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/eval"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_ExprEnv(t *testing.T) {
+	data := "MZ\x00\x00\x08\x00\x00\x00PE\x00\x00"
+	f, err := ioutil.TempFile("", "hax_env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(data)
+	f.Close()
+
+	// page is always set by main's flag default
+	display := options.RawDisplayOptions{PageSize: "4"}
+	// offset and limit are ignored, expressions are of all of the data
+	for _, rawOpts := range []options.RawOptions{
+		{Filename: f.Name(), Offset: "4", Limit: "2"},
+		{InputData: "4d5a0000080000005045 0000", Offset: "4"},
+		{InputData: "TVoAAAgAAABQRQAA", InputMode: "base64", Ranges: []string{"0:+1"}},
+	} {
+		rawOpts.Display = display
+		env := NewExprEnv(rawOpts)
+		val, err := eval.EvalExpressionEnv("size", env)
+		if err != nil || val != int64(len(data)) {
+			t.Errorf("opts: %+v, expected size: %d, got: %d, err: %v", rawOpts, len(data), val, err)
+		}
+		val, err = eval.EvalExpressionEnv("[[4:u32le]:u16be]", env)
+		if err != nil || val != 0x5045 {
+			t.Errorf("opts: %+v, expected: 0x5045, got: 0x%X, err: %v", rawOpts, val, err)
+		}
+		env.Close()
+	}
+
+	// options use the env for offset, limit and range
+	rawOpts := options.RawOptions{Filename: f.Name(), Offset: "[4:u32le]", Limit: "size - [4:u32le] - 2", Display: display}
+	env := NewExprEnv(rawOpts)
+	defer env.Close()
+	rawOpts.ExprEnv = env
+	opts, err := options.New(rawOpts)
+	if err != nil {
+		t.Fatalf("Unexpected err: %v", err)
+	}
+	if opts.Offset != 8 || opts.Limit != 2 {
+		t.Errorf("Expected offset: 8 and limit: 2, got: %d and %d", opts.Offset, opts.Limit)
+	}
+
+	_, err = eval.EvalExpressionEnv("size", NewExprEnv(options.RawOptions{}))
+	if err == nil || !strings.Contains(err.Error(), "need --file or --str input, not stdin") {
+		t.Errorf("Expected stdin error, got: %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package input
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Single char escapes shared by C and python string literals.
+var simpleEscapes = map[byte]byte{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '\'': '\'', '"': '"', '?': '?',
+}
+
+// CStringDecoder decodes C/Python string literal escapes: "\n", "\0" and
+// "\123" octal, "\xAB", "é" and "\U0001F600" (as UTF-8), etc.
+// If the input starts with a quote (or a b"" bytes prefix) the literal's
+// quotes are dropped and adjacent literals like "abc" "def" are joined,
+// otherwise everything is the string's contents.
+type CStringDecoder struct {
+	wrapped io.Reader
+	readBuf []byte
+	started bool
+	// ex: '"' or '\'' if the input is quoted, 0 if not.
+	quote byte
+	// whether inside a quoted literal
+	inside bool
+	// a 'b' at the start that may or may not be a bytes prefix like b"abc"
+	pendingPrefix bool
+	// escape sequence in progress, starting with the '\'
+	escape       []byte
+	escapeOffset int64
+	offset       int64
+	out          []byte
+	err          error
+}
+
+func NewCStringDecoder(reader io.Reader) *CStringDecoder {
+	return &CStringDecoder{
+		wrapped: reader,
+		readBuf: make([]byte, readerBufferSize),
+	}
+}
+
+func (d *CStringDecoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 && d.err == nil {
+		n, err := d.wrapped.Read(d.readBuf)
+		for _, c := range d.readBuf[:n] {
+			// a char ending a variable length escape (ex: octal) is then handled on its own.
+			for !d.addChar(c) && d.err == nil {
+			}
+			d.offset++
+			if d.err != nil {
+				break
+			}
+		}
+		if d.err == nil && err != nil {
+			if err == io.EOF {
+				if d.pendingPrefix {
+					d.pendingPrefix = false
+					d.out = append(d.out, 'b')
+				}
+				if len(d.escape) > 0 {
+					d.endEscape()
+				}
+				if d.err == nil && d.inside {
+					d.err = fmt.Errorf("Unterminated string literal, missing closing %c", d.quote)
+				}
+				if d.err != nil {
+					err = d.err
+				}
+			}
+			d.err = err
+		}
+	}
+	if len(d.out) > 0 {
+		n := copy(p, d.out)
+		d.out = d.out[n:]
+		return n, nil
+	}
+	return 0, d.err
+}
+
+// addChar handles the next char of input, returns false if it wasn't used
+// and needs to be added again.
+func (d *CStringDecoder) addChar(c byte) bool {
+	if !d.started {
+		if d.pendingPrefix {
+			d.pendingPrefix = false
+			d.started = true
+			if c == '"' || c == '\'' {
+				d.quote = c
+				d.inside = true
+				return true
+			}
+			d.out = append(d.out, 'b')
+			return false
+		}
+		if c == 'b' || c == 'B' {
+			d.pendingPrefix = true
+			return true
+		}
+		d.started = true
+		if c == '"' || c == '\'' {
+			d.quote = c
+			d.inside = true
+			return true
+		}
+	}
+
+	if len(d.escape) > 0 {
+		return d.addEscapeChar(c)
+	}
+	if d.quote != 0 && !d.inside {
+		switch c {
+		case '"', '\'':
+			d.quote = c
+			d.inside = true
+		case ' ', '\t', '\r', '\n', 'b', 'B':
+			// whitespace and bytes prefixes between literals
+		default:
+			d.err = fmt.Errorf("Unexpected %q between string literals at offset %d", c, d.offset)
+		}
+		return true
+	}
+	switch {
+	case c == '\\':
+		d.escape = append(d.escape, c)
+		d.escapeOffset = d.offset
+	case d.quote != 0 && c == d.quote:
+		d.inside = false
+	default:
+		d.out = append(d.out, c)
+	}
+	return true
+}
+
+func isOctalDigit(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func (d *CStringDecoder) addEscapeChar(c byte) bool {
+	if len(d.escape) == 1 {
+		if b, found := simpleEscapes[c]; found {
+			d.out = append(d.out, b)
+			d.escape = d.escape[:0]
+			return true
+		}
+		switch {
+		case c == '\n':
+			// line continuation
+			d.escape = d.escape[:0]
+		case isOctalDigit(c), c == 'x', c == 'u', c == 'U':
+			d.escape = append(d.escape, c)
+		default:
+			d.err = fmt.Errorf("Invalid escape '%s' at offset %d", string(d.escape)+string(c), d.escapeOffset)
+		}
+		return true
+	}
+
+	kind := d.escape[1]
+	numDigits := len(d.escape) - 1
+	maxDigits := 3
+	isDigit := isOctalDigit(c)
+	switch kind {
+	case 'x':
+		numDigits, maxDigits, isDigit = len(d.escape)-2, 2, isHexDigit(c)
+	case 'u':
+		numDigits, maxDigits, isDigit = len(d.escape)-2, 4, isHexDigit(c)
+	case 'U':
+		numDigits, maxDigits, isDigit = len(d.escape)-2, 8, isHexDigit(c)
+	}
+	if !isDigit || numDigits == maxDigits {
+		d.endEscape()
+		return false
+	}
+	d.escape = append(d.escape, c)
+	if numDigits+1 == maxDigits {
+		d.endEscape()
+	}
+	return true
+}
+
+// endEscape adds the byte(s) for the complete escape sequence.
+func (d *CStringDecoder) endEscape() {
+	escape := string(d.escape)
+	d.escape = d.escape[:0]
+	if len(escape) < 2 {
+		d.err = fmt.Errorf("Incomplete escape '%s' at offset %d", escape, d.escapeOffset)
+		return
+	}
+	switch escape[1] {
+	case 'x':
+		if len(escape) < 3 {
+			d.err = fmt.Errorf("Incomplete escape '%s' at offset %d", escape, d.escapeOffset)
+			return
+		}
+		value, _ := strconv.ParseUint(escape[2:], 16, 8)
+		d.out = append(d.out, byte(value))
+	case 'u', 'U':
+		numDigits := 4
+		if escape[1] == 'U' {
+			numDigits = 8
+		}
+		if len(escape) != numDigits+2 {
+			d.err = fmt.Errorf("Incomplete escape '%s' at offset %d, needs %d hex digits", escape, d.escapeOffset, numDigits)
+			return
+		}
+		value, _ := strconv.ParseUint(escape[2:], 16, 32)
+		if value > utf8.MaxRune || (value >= 0xD800 && value <= 0xDFFF) {
+			d.err = fmt.Errorf("Invalid unicode escape '%s' at offset %d", escape, d.escapeOffset)
+			return
+		}
+		var encoded [utf8.UTFMax]byte
+		n := utf8.EncodeRune(encoded[:], rune(value))
+		d.out = append(d.out, encoded[:n]...)
+	default: // octal
+		value, _ := strconv.ParseUint(escape[1:], 8, 16)
+		if value > 0xFF {
+			d.err = fmt.Errorf("Octal escape '%s' at offset %d is larger than \\377", escape, d.escapeOffset)
+			return
+		}
+		d.out = append(d.out, byte(value))
+	}
+}
+
+// PercentDecoder decodes URL percent-encoding, ex: "a%20b" is "a b".
+// Newlines are ignored since they can't be part of encoded data.
+type PercentDecoder struct {
+	wrapped     io.Reader
+	plusAsSpace bool
+	readBuf     []byte
+	// "%" and any hex digits of an escape in progress
+	escape []byte
+	offset int64
+	out    []byte
+	err    error
+}
+
+func NewPercentDecoder(reader io.Reader, plusAsSpace bool) *PercentDecoder {
+	return &PercentDecoder{
+		wrapped:     reader,
+		plusAsSpace: plusAsSpace,
+		readBuf:     make([]byte, readerBufferSize),
+	}
+}
+
+func (d *PercentDecoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 && d.err == nil {
+		n, err := d.wrapped.Read(d.readBuf)
+		for _, c := range d.readBuf[:n] {
+			switch {
+			case c == '\r' || c == '\n':
+			case len(d.escape) > 0:
+				if !isHexDigit(c) {
+					d.err = fmt.Errorf("Invalid percent escape '%s' at offset %d", string(d.escape)+string(c), d.offset-int64(len(d.escape)))
+					break
+				}
+				d.escape = append(d.escape, c)
+				if len(d.escape) == 3 {
+					value, _ := strconv.ParseUint(string(d.escape[1:]), 16, 8)
+					d.out = append(d.out, byte(value))
+					d.escape = d.escape[:0]
+				}
+			case c == '%':
+				d.escape = append(d.escape, c)
+			case c == '+' && d.plusAsSpace:
+				d.out = append(d.out, ' ')
+			default:
+				d.out = append(d.out, c)
+			}
+			d.offset++
+			if d.err != nil {
+				break
+			}
+		}
+		if d.err == nil && err != nil {
+			if err == io.EOF && len(d.escape) > 0 {
+				err = fmt.Errorf("Incomplete percent escape '%s' at offset %d", string(d.escape), d.offset-int64(len(d.escape)))
+			}
+			d.err = err
+		}
+	}
+	if len(d.out) > 0 {
+		n := copy(p, d.out)
+		d.out = d.out[n:]
+		return n, nil
+	}
+	return 0, d.err
+}
@@ -2,6 +2,8 @@ package input
 
 import (
 	"bufio"
+	"encoding/ascii85"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
@@ -10,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/jcuga/hax/options"
+	"github.com/jcuga/hax/z85"
 )
 
 const (
@@ -22,7 +25,10 @@ const (
 // less.  This allows us to easily read and get back expected amount of
 // data without having to worry about base64 vs hex vs raw which all have
 // different input vs represented byte lengths.
-func GetInput(opts options.Options) (*FixedLengthBufferedReader, io.Closer, bool, error) {
+// A negative opts.Offset or an opts.TrimEnd (both relative to the end of the
+// data) are resolved into the absolute opts.Offset and opts.Limit, likewise
+// for each of opts.Ranges.
+func GetInput(opts *options.Options) (*FixedLengthBufferedReader, io.Closer, bool, error) {
 	var reader io.Reader
 	var closer io.Closer
 	// flag for whether we Seek on a raw formatted file to enforce opts.Offset
@@ -33,6 +39,8 @@ func GetInput(opts options.Options) (*FixedLengthBufferedReader, io.Closer, bool
 	// "true" bytes are skipped since we're dealing with base64/hex
 	// "synthetic" bytes input.
 	fileOffsetOptimization := false
+	// raw file that ranges can be read from directly
+	var rawFile *os.File
 	isStdin := false
 	if len(opts.InputData) > 0 {
 		reader = strings.NewReader(opts.InputData)
@@ -44,19 +52,58 @@ func GetInput(opts options.Options) (*FixedLengthBufferedReader, io.Closer, bool
 		}
 		reader = f
 		closer = f
-		if opts.Offset > 0 && opts.InputMode == options.Raw {
-			if _, err := f.Seek(opts.Offset, os.SEEK_SET); err != nil {
+		if opts.InputMode == options.Auto && opts.Decompress == options.NoCompression {
+			// Detect now to know if can seek. ReadAt doesn't change the file's offset.
+			sample := make([]byte, detectSampleSize)
+			n, err := f.ReadAt(sample, 0)
+			if err != nil && err != io.EOF {
 				defer f.Close()
-				return nil, nil, isStdin, fmt.Errorf("Failed to seek offset: %d on input file, error: %v", opts.Offset, err)
+				return nil, nil, isStdin, fmt.Errorf("Failed to read input file to detect mode, error: %v", err)
+			}
+			opts.InputMode = detectInputMode(sample[:n], err == io.EOF)
+		}
+		// NOTE: offset is of the decompressed data when decompressing, so can't seek.
+		if (opts.Offset != 0 || opts.TrimEnd > 0) && opts.InputMode == options.Raw && opts.Decompress == options.NoCompression {
+			if err := seekFileRange(f, opts); err != nil {
+				defer f.Close()
+				return nil, nil, isStdin, err
 			}
 			fileOffsetOptimization = true
 		}
+		if opts.InputMode == options.Raw && opts.Decompress == options.NoCompression {
+			rawFile = f
+		}
 	} else {
 		reader = bufio.NewReader(os.Stdin)
 		closer = nil
 		isStdin = true
 	}
 
+	if opts.Decompress != options.NoCompression {
+		decompressed, err := NewDecompressReader(reader, opts.Decompress)
+		if err != nil {
+			if closer != nil {
+				closer.Close()
+			}
+			return nil, nil, isStdin, err
+		}
+		reader = decompressed
+	}
+
+	if opts.InputMode == options.Auto {
+		// Peek to keep the sampled data for the mode's reader
+		bufReader := bufio.NewReaderSize(reader, detectSampleSize)
+		sample, err := bufReader.Peek(detectSampleSize)
+		if err != nil && err != io.EOF {
+			if closer != nil {
+				closer.Close()
+			}
+			return nil, nil, isStdin, fmt.Errorf("Failed to read input to detect mode, error: %v", err)
+		}
+		opts.InputMode = detectInputMode(sample, err == io.EOF)
+		reader = bufReader
+	}
+
 	// Now turn reader into reader with given mode...
 	var modeReader io.Reader
 	switch opts.InputMode {
@@ -79,17 +126,81 @@ func GetInput(opts options.Options) (*FixedLengthBufferedReader, io.Closer, bool
 		},
 			3)) // NOTE: 3 arg is to skip every unignored-count % 3 bytes to ignore the '0' from "0xAA"
 		// which will be filterd to just "0AA".
-	case options.Base64:
-		modeReader = base64.NewDecoder(base64.StdEncoding, NewFilteringReader(reader, []byte{
+	case options.Base64, options.Base64Url, options.Base64Raw, options.Base64UrlRaw, options.Base64Mime:
+		// All base64 variants are accepted regardless of which one was picked,
+		// the alphabet and any missing padding are normalized to std base64.
+		modeReader = base64.NewDecoder(base64.StdEncoding, NewBase64NormalizingReader(NewFilteringReader(reader, []byte{
+			'\r', '\n', '\t', ' ',
+		})))
+	case options.Display:
+		modeReader = NewDisplayReader(reader)
+	case options.Xxd:
+		modeReader = NewXxdReader(reader)
+	case options.HexdumpCanonical:
+		modeReader = NewHexdumpCanonicalReader(reader)
+	case options.Od:
+		modeReader = NewOdReader(reader)
+	case options.Binary:
+		modeReader = NewBinaryDecoder(NewFilteringReader(reader, []byte{
+			'\r', '\n', '\t', ' ', ',', '_',
+		}), opts.LsbFirst)
+	case options.Base32, options.Base32Hex:
+		encoding := base32.StdEncoding
+		if opts.InputMode == options.Base32Hex {
+			encoding = base32.HexEncoding
+		}
+		modeReader = base32.NewDecoder(encoding, NewFilteringReader(reader, []byte{
 			'\r', '\n', '\t', ' ',
 		}))
+	case options.Ascii85, options.Ascii85Delimited:
+		// "<~" and "~>" are optional for either mode, the ascii85 decoder already ignores whitespace.
+		modeReader = ascii85.NewDecoder(NewAscii85DelimiterReader(reader))
+	case options.Z85:
+		modeReader = z85.NewDecoder(reader)
+	case options.DecList:
+		modeReader = NewNumberListDecoder(reader, 10, opts.Separator, !opts.NoRangeCheck)
+	case options.OctList:
+		modeReader = NewNumberListDecoder(reader, 8, opts.Separator, !opts.NoRangeCheck)
+	case options.CString:
+		modeReader = NewCStringDecoder(reader)
+	case options.UrlEncoded:
+		modeReader = NewPercentDecoder(reader, opts.PlusAsSpace)
+	case options.IntelHex:
+		modeReader = NewIntelHexReader(reader, opts.FillByte, opts.Sparse, os.Stderr, opts.BaseAddress)
+	case options.SRecord:
+		modeReader = NewSRecordReader(reader, opts.FillByte, opts.Sparse, os.Stderr, opts.BaseAddress)
 	default:
 		return nil, closer, isStdin, fmt.Errorf("Invalid input mode: %v", opts.InputMode)
 	}
 
+	if len(opts.Ranges) > 1 {
+		ranged, err := readRanges(modeReader, rawFile, opts)
+		if err != nil {
+			return nil, closer, isStdin, err
+		}
+		return NewFixedLengthBufferedReader(ranged), closer, isStdin, nil
+	}
+
+	// a negative offset is resolved by reading to the end, no need to skip ahead after.
+	skipToOffset := opts.Offset > 0 && !fileOffsetOptimization
+	if opts.Offset < 0 && !fileOffsetOptimization {
+		tail := NewTailReader(modeReader, -opts.Offset)
+		start, err := tail.Fill()
+		if err != nil {
+			return nil, closer, isStdin, fmt.Errorf("Error reading data: %v", err)
+		}
+		opts.Offset = start
+		// now know how much data there is, so trimming the end is just a limit.
+		limitTrimmedEnd(opts, int64(len(tail.out)))
+		modeReader = tail
+	} else if opts.TrimEnd > 0 && !fileOffsetOptimization {
+		modeReader = NewTrimEndReader(modeReader, opts.TrimEnd)
+		opts.TrimEnd = 0
+	}
+
 	fixedReader := NewFixedLengthBufferedReader(modeReader)
 
-	if opts.Offset > 0 && !fileOffsetOptimization {
+	if skipToOffset {
 		// Lazy seek--just read enough data and discard.
 		seekBufSize := int64(readerBufferSize)
 		curOffset := int64(0)
@@ -108,3 +219,54 @@ func GetInput(opts options.Options) (*FixedLengthBufferedReader, io.Closer, bool
 
 	return fixedReader, closer, isStdin, nil
 }
+
+// seekFileRange seeks to opts.Offset in a raw file, resolving a negative
+// offset and opts.TrimEnd using the size of the file.
+func seekFileRange(f *os.File, opts *options.Options) error {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("Failed to seek end of input file, error: %v", err)
+	}
+	if opts.Offset < 0 {
+		opts.Offset += size
+		if opts.Offset < 0 {
+			opts.Offset = 0
+		}
+	}
+	if _, err := f.Seek(opts.Offset, io.SeekStart); err != nil {
+		return fmt.Errorf("Failed to seek offset: %d on input file, error: %v", opts.Offset, err)
+	}
+	limitTrimmedEnd(opts, size-opts.Offset)
+	return nil
+}
+
+// limitTrimmedEnd turns opts.TrimEnd into a limit once the amount of data
+// after opts.Offset is known.
+func limitTrimmedEnd(opts *options.Options, remaining int64) {
+	if opts.TrimEnd <= 0 {
+		return
+	}
+	limit := remaining - opts.TrimEnd
+	if limit < 0 {
+		limit = 0
+	}
+	if limit < opts.Limit {
+		opts.Limit = limit
+	}
+	opts.TrimEnd = 0
+}
+
+// detectInputMode picks the mode for --input auto, noting the choice on
+// stderr when the data could be something else.
+func detectInputMode(sample []byte, complete bool) options.IOMode {
+	mode, alternatives := DetectInputMode(sample, complete)
+	if len(alternatives) > 0 {
+		names := make([]string, len(alternatives))
+		for i, alt := range alternatives {
+			names[i] = options.IOModeToString(alt)
+		}
+		fmt.Fprintf(os.Stderr, "Detected input mode: %s (could also be %s, use --input to choose)\n",
+			options.IOModeToString(mode), strings.Join(names, " or "))
+	}
+	return mode
+}
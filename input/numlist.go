@@ -0,0 +1,120 @@
+package input
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// NumberListDecoder decodes a list of decimal or octal numbers into one byte
+// each, ex: "72, 101, 108" or "0110 0145 0154". Values from -128 to 255 are
+// accepted so signed byte arrays (ex: from java) work too. Whitespace, commas,
+// brackets and any extra separator chars are skipped between numbers.
+type NumberListDecoder struct {
+	wrapped    io.Reader
+	base       int
+	separators string
+	// If false, values outside of -128 to 255 keep their low 8 bits instead of erroring.
+	rangeCheck bool
+	readBuf    []byte
+	token      []byte
+	// offset of the current token within the input and its 1-based item number
+	tokenOffset int64
+	numItems    int64
+	offset      int64
+	out         []byte
+	err         error
+}
+
+func NewNumberListDecoder(reader io.Reader, base int, separators string, rangeCheck bool) *NumberListDecoder {
+	return &NumberListDecoder{
+		wrapped:    reader,
+		base:       base,
+		separators: " \t\r\n,;[]{}()" + separators,
+		rangeCheck: rangeCheck,
+		readBuf:    make([]byte, readerBufferSize),
+	}
+}
+
+func (d *NumberListDecoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 && d.err == nil {
+		n, err := d.wrapped.Read(d.readBuf)
+		for _, c := range d.readBuf[:n] {
+			if strings.IndexByte(d.separators, c) >= 0 {
+				d.err = d.endToken()
+			} else {
+				if len(d.token) == 0 {
+					d.tokenOffset = d.offset
+				}
+				d.token = append(d.token, c)
+			}
+			d.offset++
+			if d.err != nil {
+				break
+			}
+		}
+		if d.err == nil && err != nil {
+			if err == io.EOF {
+				if tokenErr := d.endToken(); tokenErr != nil {
+					err = tokenErr
+				}
+			}
+			d.err = err
+		}
+	}
+	if len(d.out) > 0 {
+		n := copy(p, d.out)
+		d.out = d.out[n:]
+		return n, nil
+	}
+	return 0, d.err
+}
+
+// endToken parses the current token, if any, and adds its byte to the output.
+func (d *NumberListDecoder) endToken() error {
+	if len(d.token) == 0 {
+		return nil
+	}
+	d.numItems++
+	token := string(d.token)
+	d.token = d.token[:0]
+
+	digits := token
+	sign := ""
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	baseName := "decimal"
+	if d.base == 8 {
+		baseName = "octal"
+		if strings.HasPrefix(digits, "0o") || strings.HasPrefix(digits, "0O") {
+			digits = digits[2:]
+		}
+	}
+	// NOTE: digits can't be empty or have another sign, ParseInt allows a single leading sign.
+	if digits == "" || digits[0] == '-' || digits[0] == '+' {
+		return fmt.Errorf("Invalid %s value %q (item %d, input offset %d)", baseName, token, d.numItems, d.tokenOffset)
+	}
+	value, err := strconv.ParseInt(sign+digits, d.base, 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); !ok || numErr.Err != strconv.ErrRange {
+			return fmt.Errorf("Invalid %s value %q (item %d, input offset %d)", baseName, token, d.numItems, d.tokenOffset)
+		}
+		if d.rangeCheck {
+			return fmt.Errorf("Value %q out of range -128 to 255 (item %d, input offset %d)", token, d.numItems, d.tokenOffset)
+		}
+		// too large for even int64, so get the low 8 bits digit by digit.
+		value = 0
+		for _, c := range digits {
+			value = (value*int64(d.base) + int64(c-'0')) % 256
+		}
+		if sign == "-" {
+			value = -value
+		}
+	} else if d.rangeCheck && (value < -128 || value > 255) {
+		return fmt.Errorf("Value %q out of range -128 to 255 (item %d, input offset %d)", token, d.numItems, d.tokenOffset)
+	}
+	d.out = append(d.out, byte(value))
+	return nil
+}
@@ -0,0 +1,227 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+
+	"github.com/jcuga/hax/options"
+)
+
+// resolveRange gets the absolute offset and length of r within size bytes.
+func resolveRange(r options.Range, size int64) (int64, int64) {
+	start := r.Offset
+	if start < 0 {
+		start += size
+		if start < 0 {
+			start = 0
+		}
+	}
+	if start > size {
+		start = size
+	}
+	length := size - start - r.TrimEnd
+	if length < 0 {
+		length = 0
+	}
+	if r.Limit < length {
+		length = r.Limit
+	}
+	return start, length
+}
+
+// readRanges gets a reader of each of opts.Ranges' data one after another,
+// updating them to absolute offsets and exact lengths (for streamed ones,
+// once read to the end of the data). A raw file is read from directly.
+// Otherwise sorted ranges from the start of the data are streamed, and
+// anything else only keeps the data the ranges could need.
+func readRanges(reader io.Reader, file *os.File, opts *options.Options) (io.Reader, error) {
+	if file != nil {
+		size, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to seek end of input file, error: %v", err)
+		}
+		readers := make([]io.Reader, len(opts.Ranges))
+		for i, r := range opts.Ranges {
+			start, length := resolveRange(r, size)
+			opts.Ranges[i] = options.Range{Offset: start, Limit: length}
+			readers[i] = io.NewSectionReader(file, start, length)
+		}
+		return io.MultiReader(readers...), nil
+	}
+	if rangesSorted(opts.Ranges) {
+		return NewRangesReader(reader, opts.Ranges), nil
+	}
+	return readKeptRanges(reader, opts)
+}
+
+// rangesSorted is whether ranges are all from the start of the data, in
+// order and not overlapping, so they can be read without going back.
+func rangesSorted(ranges []options.Range) bool {
+	for i, r := range ranges {
+		if r.Offset < 0 || r.TrimEnd > 0 {
+			return false
+		}
+		if i > 0 {
+			prev := ranges[i-1]
+			if r.Offset < prev.Offset || prev.Limit > r.Offset-prev.Offset {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// RangesReader yields the data of sorted ranges one after another, skipping
+// the data between them. The ranges are updated in place with exact lengths
+// once the end of the data is reached.
+type RangesReader struct {
+	wrapped io.Reader
+	ranges  []options.Range
+	index   int   // of the range being read
+	copied  int64 // of ranges[index]
+	pos     int64 // in the wrapped data
+	err     error
+}
+
+func NewRangesReader(reader io.Reader, ranges []options.Range) *RangesReader {
+	return &RangesReader{
+		wrapped: reader,
+		ranges:  ranges,
+	}
+}
+
+func (r *RangesReader) Read(p []byte) (int, error) {
+	for r.index < len(r.ranges) {
+		cur := r.ranges[r.index]
+		if r.err == nil && r.pos < cur.Offset {
+			n, err := io.CopyN(ioutil.Discard, r.wrapped, cur.Offset-r.pos)
+			r.pos += n
+			r.err = err
+			continue
+		}
+		if r.pos >= cur.Offset && r.copied >= cur.Limit {
+			r.index++
+			r.copied = 0
+			continue
+		}
+		if r.err != nil {
+			if r.err == io.EOF {
+				r.trimToEnd()
+			}
+			return 0, r.err
+		}
+		want := cur.Limit - r.copied
+		if want > int64(len(p)) {
+			want = int64(len(p))
+		}
+		n, err := r.wrapped.Read(p[:want])
+		r.pos += int64(n)
+		r.copied += int64(n)
+		r.err = err
+		if n > 0 {
+			return n, nil
+		}
+	}
+	return 0, io.EOF
+}
+
+// trimToEnd sets the exact lengths of the ranges now that the data ended at
+// r.pos, same as resolveRange would have.
+func (r *RangesReader) trimToEnd() {
+	for i := r.index; i < len(r.ranges); i++ {
+		if r.ranges[i].Offset > r.pos {
+			r.ranges[i].Offset = r.pos
+		}
+		r.ranges[i].Limit = 0
+	}
+	r.ranges[r.index].Limit = r.copied
+	r.index = len(r.ranges)
+}
+
+// readKeptRanges reads all of the data to resolve opts.Ranges, but only keeps
+// the data from the first range start to the last range end along with as
+// much of the end of the data as negative offsets reach back.
+func readKeptRanges(reader io.Reader, opts *options.Options) (io.Reader, error) {
+	kept := keptData{from: math.MaxInt64}
+	tailSize := int64(0)
+	for _, r := range opts.Ranges {
+		switch {
+		case r.Offset < 0:
+			// starts within the last -r.Offset bytes
+			if -r.Offset > tailSize {
+				tailSize = -r.Offset
+			}
+		case r.TrimEnd > 0 || r.Limit > math.MaxInt64-r.Offset:
+			// to somewhere near the end of the data
+			if r.Offset < kept.from {
+				kept.from = r.Offset
+			}
+			kept.to = math.MaxInt64
+		default:
+			if r.Offset < kept.from {
+				kept.from = r.Offset
+			}
+			if r.Offset+r.Limit > kept.to {
+				kept.to = r.Offset + r.Limit
+			}
+		}
+	}
+
+	var tail []byte
+	teed := io.TeeReader(reader, &kept)
+	if tailSize > 0 {
+		tailReader := NewTailReader(teed, tailSize)
+		if _, err := tailReader.Fill(); err != nil {
+			return nil, fmt.Errorf("Error reading data: %v", err)
+		}
+		tail = tailReader.out
+	} else {
+		// without negative offsets the size only matters up to the last range end
+		if _, err := io.Copy(ioutil.Discard, io.LimitReader(teed, kept.to)); err != nil {
+			return nil, fmt.Errorf("Error reading data: %v", err)
+		}
+	}
+	size := kept.pos
+	tailStart := size - int64(len(tail))
+
+	readers := make([]io.Reader, len(opts.Ranges))
+	for i, r := range opts.Ranges {
+		start, length := resolveRange(r, size)
+		opts.Ranges[i] = options.Range{Offset: start, Limit: length}
+		if start >= tailStart {
+			readers[i] = bytes.NewReader(tail[start-tailStart : start-tailStart+length])
+		} else {
+			readers[i] = bytes.NewReader(kept.data[start-kept.from : start-kept.from+length])
+		}
+	}
+	return io.MultiReader(readers...), nil
+}
+
+// keptData is written all of the data but only keeps what is from offset
+// from up to to.
+type keptData struct {
+	from int64
+	to   int64
+	pos  int64 // of the next byte written
+	data []byte
+}
+
+func (k *keptData) Write(p []byte) (int, error) {
+	pos := k.pos
+	k.pos += int64(len(p))
+	start, end := k.from-pos, k.to-pos
+	if start < 0 {
+		start = 0
+	}
+	if end > int64(len(p)) {
+		end = int64(len(p))
+	}
+	if start < end {
+		k.data = append(k.data, p[start:end]...)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,141 @@
+package input
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jcuga/hax/options"
+)
+
+func Test_GetInput_Ranges(t *testing.T) {
+	data := "0123456789abcdefghij"
+	f, err := ioutil.TempFile("", "hax_ranges")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(data)
+	f.Close()
+
+	type testCase struct {
+		ranges         []options.Range
+		expected       string
+		expectedRanges []options.Range
+	}
+	cases := []testCase{
+		{
+			[]options.Range{{Offset: 0, Limit: 4}, {Offset: -4, Limit: math.MaxInt64}},
+			"0123ghij",
+			[]options.Range{{Offset: 0, Limit: 4}, {Offset: 16, Limit: 4}},
+		},
+		{
+			// out of order and overlapping
+			[]options.Range{{Offset: 10, Limit: 2}, {Offset: 5, Limit: math.MaxInt64, TrimEnd: 12}, {Offset: 8, Limit: 4}},
+			"ab56789ab",
+			[]options.Range{{Offset: 10, Limit: 2}, {Offset: 5, Limit: 3}, {Offset: 8, Limit: 4}},
+		},
+		{
+			// sorted are streamed
+			[]options.Range{{Offset: 2, Limit: 3}, {Offset: 8, Limit: 2}, {Offset: 15, Limit: math.MaxInt64}},
+			"23489fghij",
+			[]options.Range{{Offset: 2, Limit: 3}, {Offset: 8, Limit: 2}, {Offset: 15, Limit: 5}},
+		},
+		{
+			// sorted, empty and past the end
+			[]options.Range{{Offset: 0, Limit: 0}, {Offset: 5, Limit: 2}, {Offset: 18, Limit: 10}, {Offset: 25, Limit: 0}, {Offset: 30, Limit: 5}},
+			"56ij",
+			[]options.Range{{Offset: 0, Limit: 0}, {Offset: 5, Limit: 2}, {Offset: 18, Limit: 2}, {Offset: 20, Limit: 0}, {Offset: 20, Limit: 0}},
+		},
+		{
+			// end-relative and from the start
+			[]options.Range{{Offset: 1, Limit: 2}, {Offset: 16, Limit: math.MaxInt64, TrimEnd: 1}},
+			"12ghi",
+			[]options.Range{{Offset: 1, Limit: 2}, {Offset: 16, Limit: 3}},
+		},
+		{
+			// empty and past the end
+			[]options.Range{{Offset: 3, Limit: 0}, {Offset: 18, Limit: 10}, {Offset: 50, Limit: 5}, {Offset: -50, Limit: 2}},
+			"ij01",
+			[]options.Range{{Offset: 3, Limit: 0}, {Offset: 18, Limit: 2}, {Offset: 20, Limit: 0}, {Offset: 0, Limit: 2}},
+		},
+	}
+	for _, c := range cases {
+		for _, inOpts := range []options.Options{
+			{Filename: f.Name(), InputMode: options.Raw},
+			{InputData: data, InputMode: options.Raw},
+			{InputData: "MDEyMzQ1Njc4OWFiY2RlZmdoaWo=", InputMode: options.Base64},
+		} {
+			opts := inOpts
+			opts.Limit = math.MaxInt64
+			opts.Ranges = append([]options.Range{}, c.ranges...)
+			reader, closer, _, err := GetInput(&opts)
+			if err != nil {
+				t.Errorf("ranges: %v, file: %q, unexpected err: %v", c.ranges, opts.Filename, err)
+				continue
+			}
+			result, err := ioutil.ReadAll(reader)
+			if closer != nil {
+				closer.Close()
+			}
+			if err != nil {
+				t.Errorf("ranges: %v, file: %q, unexpected read err: %v", c.ranges, opts.Filename, err)
+			}
+			if string(result) != c.expected {
+				t.Errorf("ranges: %v, file: %q, expected: %q, got: %q", c.ranges, opts.Filename, c.expected, result)
+			}
+			if !reflect.DeepEqual(opts.Ranges, c.expectedRanges) {
+				t.Errorf("ranges: %v, file: %q, expected resolved ranges: %v, got: %v",
+					c.ranges, opts.Filename, c.expectedRanges, opts.Ranges)
+			}
+		}
+	}
+}
+
+// repeatReader yields its data over and over without ever ending.
+type repeatReader struct {
+	data string
+	pos  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.data[r.pos%len(r.data)]
+		r.pos++
+	}
+	return len(p), nil
+}
+
+// Tests that ranges from the start of the data don't need the end of it.
+func Test_GetInput_RangesEndless(t *testing.T) {
+	type testCase struct {
+		ranges   []options.Range
+		expected string
+	}
+	cases := []testCase{
+		// streamed
+		{[]options.Range{{Offset: 2, Limit: 3}, {Offset: 0x100008, Limit: 2}}, "23489"},
+		// out of order, kept from 2 to 0x10000A
+		{[]options.Range{{Offset: 0x100008, Limit: 2}, {Offset: 2, Limit: 3}}, "89234"},
+	}
+	for _, c := range cases {
+		opts := options.Options{Limit: math.MaxInt64, Ranges: append([]options.Range{}, c.ranges...)}
+		reader, err := readRanges(&repeatReader{data: "0123456789abcdef"}, nil, &opts)
+		if err != nil {
+			t.Errorf("ranges: %v, unexpected err: %v", c.ranges, err)
+			continue
+		}
+		result, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Errorf("ranges: %v, unexpected read err: %v", c.ranges, err)
+		}
+		if string(result) != c.expected {
+			t.Errorf("ranges: %v, expected: %q, got: %q", c.ranges, c.expected, result)
+		}
+		if !reflect.DeepEqual(opts.Ranges, c.ranges) {
+			t.Errorf("ranges: %v, expected resolved ranges to be the same, got: %v", c.ranges, opts.Ranges)
+		}
+	}
+}
@@ -81,6 +81,8 @@ type FixedLengthBufferedReader struct {
 	buf          []byte
 	bufFilledLen int
 	bufIndex     int
+	// error from wrapped reader held until buffered data is used up
+	err error
 }
 
 func NewFixedLengthBufferedReader(reader io.Reader) *FixedLengthBufferedReader {
@@ -104,6 +106,12 @@ func (r *FixedLengthBufferedReader) Read(p []byte) (int, error) {
 		r.bufIndex += reqLen
 		return reqLen, nil
 	}
+	if r.err != nil {
+		// wrapped reader is done, only buffered data is left
+		copy(p, r.buf[r.bufIndex:r.bufFilledLen])
+		r.bufIndex = r.bufFilledLen
+		return bufferedLen, r.err
+	}
 
 	if bufferedLen > 0 { // have some amount < reqLen but not zero
 		// copy remaining buffered data
@@ -132,6 +140,12 @@ func (r *FixedLengthBufferedReader) Read(p []byte) (int, error) {
 		if n >= outstandingLen {
 			copy(p[alreadyReadLen:], r.buf[:outstandingLen])
 			r.bufIndex += outstandingLen
+			if n > outstandingLen && err != nil {
+				// readers may return data along with EOF, don't report it
+				// until the rest of the data is read.
+				r.err = err
+				return alreadyReadLen + outstandingLen, nil
+			}
 			return alreadyReadLen + outstandingLen, err
 		}
 
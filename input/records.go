@@ -0,0 +1,304 @@
+package input
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// dataRecord is the address and data of one record, or end set for a
+// record marking the end of the file.
+type dataRecord struct {
+	address int64
+	data    []byte
+	end     bool
+	lineNum int
+}
+
+// recordSegment is contiguous data merged from one or more records.
+type recordSegment struct {
+	address int64
+	data    []byte
+	// line of the record at address
+	lineNum int
+}
+
+// RecordReader parses Intel HEX or Motorola S-record files into bytes in
+// address order. Output starts at baseAddress, or the lowest record address
+// if baseAddress < 0. Gaps between records are filled with fillByte unless
+// sparse, in which case the data of each contiguous segment is concatenated
+// and the segments are reported to segmentLog. Records can be in any order
+// and can overlap as long as the overlapping bytes are the same.
+type RecordReader struct {
+	scanner     *bufio.Scanner
+	parseLine   func(line string) (dataRecord, error)
+	fillByte    byte
+	sparse      bool
+	segmentLog  io.Writer
+	baseAddress int64
+	lineNum     int
+	loaded      bool
+	// segments not output yet, in address order
+	segments []recordSegment
+	// address after the last byte output
+	nextAddress int64
+	// number of fill bytes to output before out
+	fillRemaining int64
+	out           []byte
+	err           error
+}
+
+func newRecordReader(reader io.Reader, parseLine func(line string) (dataRecord, error),
+	fillByte byte, sparse bool, segmentLog io.Writer, baseAddress int64) *RecordReader {
+	return &RecordReader{
+		scanner:     bufio.NewScanner(reader),
+		parseLine:   parseLine,
+		fillByte:    fillByte,
+		sparse:      sparse,
+		segmentLog:  segmentLog,
+		baseAddress: baseAddress,
+	}
+}
+
+// NewIntelHexReader parses Intel HEX records, ex: ":0300300002337A1E",
+// including extended segment and linear address records.
+func NewIntelHexReader(reader io.Reader, fillByte byte, sparse bool, segmentLog io.Writer, baseAddress int64) *RecordReader {
+	r := newRecordReader(reader, nil, fillByte, sparse, segmentLog, baseAddress)
+	upperAddress := int64(0)
+	r.parseLine = func(line string) (dataRecord, error) {
+		return parseIntelHexLine(line, &upperAddress)
+	}
+	return r
+}
+
+// NewSRecordReader parses Motorola S-records (S19, S28 and S37 files), ex:
+// "S1130000285F245F2212226A000424290008237C2A".
+func NewSRecordReader(reader io.Reader, fillByte byte, sparse bool, segmentLog io.Writer, baseAddress int64) *RecordReader {
+	return newRecordReader(reader, parseSRecordLine, fillByte, sparse, segmentLog, baseAddress)
+}
+
+func (r *RecordReader) Read(p []byte) (int, error) {
+	for {
+		if r.fillRemaining > 0 {
+			n := len(p)
+			if int64(n) > r.fillRemaining {
+				n = int(r.fillRemaining)
+			}
+			for i := range p[:n] {
+				p[i] = r.fillByte
+			}
+			r.fillRemaining -= int64(n)
+			return n, nil
+		}
+		if len(r.out) > 0 {
+			n := copy(p, r.out)
+			r.out = r.out[n:]
+			return n, nil
+		}
+		if r.err != nil {
+			return 0, r.err
+		}
+		if !r.loaded {
+			r.loaded = true
+			r.err = r.load()
+			continue
+		}
+		if len(r.segments) == 0 {
+			r.err = io.EOF
+			continue
+		}
+		segment := r.segments[0]
+		r.segments = r.segments[1:]
+		if !r.sparse {
+			r.fillRemaining = segment.address - r.nextAddress
+		}
+		r.out = segment.data
+		r.nextAddress = segment.address + int64(len(segment.data))
+	}
+}
+
+// load reads all of the records since they can be in any order, and merges
+// them into segments of contiguous data.
+func (r *RecordReader) load() error {
+	records, err := r.readRecords()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].address < records[j].address
+	})
+
+	for _, record := range records {
+		if len(r.segments) == 0 {
+			r.segments = append(r.segments, recordSegment{record.address, record.data, record.lineNum})
+			continue
+		}
+		last := &r.segments[len(r.segments)-1]
+		lastEnd := last.address + int64(len(last.data))
+		if record.address > lastEnd {
+			r.segments = append(r.segments, recordSegment{record.address, record.data, record.lineNum})
+			continue
+		}
+		// touches or overlaps the previous data, which must agree on any overlapping bytes
+		overlap := lastEnd - record.address
+		if overlap > int64(len(record.data)) {
+			overlap = int64(len(record.data))
+		}
+		existing := last.data[record.address-last.address:][:overlap]
+		for i := range existing {
+			if existing[i] != record.data[i] {
+				return fmt.Errorf("Record on line %d overlaps other records with different data at address 0x%X",
+					record.lineNum, record.address+int64(i))
+			}
+		}
+		if int64(len(record.data)) > overlap {
+			// copy since appending could write into another record's data
+			data := make([]byte, 0, len(last.data)+len(record.data)-int(overlap))
+			data = append(data, last.data...)
+			last.data = append(data, record.data[overlap:]...)
+		}
+	}
+
+	r.nextAddress = r.segments[0].address
+	if r.baseAddress >= 0 {
+		if r.segments[0].address < r.baseAddress {
+			return fmt.Errorf("Record on line %d at address 0x%X is before --base-address 0x%X",
+				r.segments[0].lineNum, r.segments[0].address, r.baseAddress)
+		}
+		r.nextAddress = r.baseAddress
+	}
+	if r.sparse && r.segmentLog != nil {
+		for _, segment := range r.segments {
+			fmt.Fprintf(r.segmentLog, "Segment 0x%08X-0x%08X (%d bytes)\n",
+				segment.address, segment.address+int64(len(segment.data))-1, len(segment.data))
+		}
+	}
+	return nil
+}
+
+// readRecords parses the data records of all lines up to the end of file record.
+func (r *RecordReader) readRecords() ([]dataRecord, error) {
+	records := make([]dataRecord, 0)
+	for r.scanner.Scan() {
+		r.lineNum++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		record, err := r.parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid record on line %d: %v", r.lineNum, err)
+		}
+		if record.end {
+			return records, nil
+		}
+		if len(record.data) > 0 {
+			record.lineNum = r.lineNum
+			records = append(records, record)
+		}
+	}
+	return records, r.scanner.Err()
+}
+
+// parseIntelHexLine parses ":LLAAAATT<data>CC" where LL is the data length,
+// AAAA the address, TT the record type and CC the checksum.
+// upperAddress is updated by extended address records.
+func parseIntelHexLine(line string, upperAddress *int64) (dataRecord, error) {
+	if line[0] != ':' {
+		return dataRecord{}, fmt.Errorf("Intel HEX record must start with ':', got: %q", line)
+	}
+	raw, err := hex.DecodeString(line[1:])
+	if err != nil || len(raw) < 5 {
+		return dataRecord{}, fmt.Errorf("Malformed Intel HEX record: %q", line)
+	}
+	if int(raw[0]) != len(raw)-5 {
+		return dataRecord{}, fmt.Errorf("Intel HEX record length 0x%02X does not match %d data bytes", raw[0], len(raw)-5)
+	}
+	sum := byte(0)
+	for _, b := range raw[:len(raw)-1] {
+		sum += b
+	}
+	if expected := -sum; expected != raw[len(raw)-1] {
+		return dataRecord{}, fmt.Errorf("Intel HEX checksum mismatch, expected 0x%02X, got 0x%02X", expected, raw[len(raw)-1])
+	}
+
+	address := int64(raw[1])<<8 | int64(raw[2])
+	data := raw[4 : len(raw)-1]
+	switch raw[3] {
+	case 0x00: // data
+		return dataRecord{address: *upperAddress + address, data: data}, nil
+	case 0x01: // end of file
+		return dataRecord{end: true}, nil
+	case 0x02, 0x04: // extended segment address, extended linear address
+		if len(data) != 2 {
+			return dataRecord{}, fmt.Errorf("Intel HEX address record type %02X must have 2 data bytes, got %d", raw[3], len(data))
+		}
+		*upperAddress = int64(data[0])<<8 | int64(data[1])
+		if raw[3] == 0x02 {
+			*upperAddress <<= 4
+		} else {
+			*upperAddress <<= 16
+		}
+		return dataRecord{}, nil
+	case 0x03, 0x05: // start segment address, start linear address
+		return dataRecord{}, nil
+	default:
+		return dataRecord{}, fmt.Errorf("Unknown Intel HEX record type %02X", raw[3])
+	}
+}
+
+// parseSRecordLine parses "S<type><count><address><data><checksum>" where
+// count is the number of bytes after it and the address is 2, 3 or 4 bytes
+// depending on the type.
+func parseSRecordLine(line string) (dataRecord, error) {
+	if len(line) < 2 || (line[0] != 'S' && line[0] != 's') {
+		return dataRecord{}, fmt.Errorf("S-record must start with 'S', got: %q", line)
+	}
+	raw, err := hex.DecodeString(line[2:])
+	if err != nil || len(raw) < 1 {
+		return dataRecord{}, fmt.Errorf("Malformed S-record: %q", line)
+	}
+	if int(raw[0]) != len(raw)-1 {
+		return dataRecord{}, fmt.Errorf("S-record byte count 0x%02X does not match %d bytes", raw[0], len(raw)-1)
+	}
+	sum := byte(0)
+	for _, b := range raw[:len(raw)-1] {
+		sum += b
+	}
+	if expected := ^sum; expected != raw[len(raw)-1] {
+		return dataRecord{}, fmt.Errorf("S-record checksum mismatch, expected 0x%02X, got 0x%02X", expected, raw[len(raw)-1])
+	}
+
+	addressLen := 0
+	switch line[1] {
+	case '0', '1', '5', '9':
+		addressLen = 2
+	case '2', '6', '8':
+		addressLen = 3
+	case '3', '7':
+		addressLen = 4
+	default:
+		return dataRecord{}, fmt.Errorf("Unknown S-record type S%c", line[1])
+	}
+	if len(raw) < addressLen+2 {
+		return dataRecord{}, fmt.Errorf("S-record too short for S%c address: %q", line[1], line)
+	}
+	address := int64(0)
+	for _, b := range raw[1 : 1+addressLen] {
+		address = address<<8 | int64(b)
+	}
+	switch line[1] {
+	case '1', '2', '3':
+		return dataRecord{address: address, data: raw[1+addressLen : len(raw)-1]}, nil
+	case '7', '8', '9':
+		return dataRecord{end: true}, nil
+	default: // header and record counts
+		return dataRecord{}, nil
+	}
+}
@@ -0,0 +1,114 @@
+package input
+
+import (
+	"io"
+)
+
+// TailReader yields only the last size bytes of the wrapped reader, like
+// tail -c. The wrapped reader is read to the end into a ring buffer before
+// anything can be returned.
+type TailReader struct {
+	wrapped io.Reader
+	size    int64
+	// grows up to size, then the oldest byte at next is overwritten
+	ring  []byte
+	next  int
+	total int64
+	done  bool
+	out   []byte
+	err   error
+}
+
+func NewTailReader(reader io.Reader, size int64) *TailReader {
+	return &TailReader{
+		wrapped: reader,
+		size:    size,
+	}
+}
+
+// Fill reads all of the wrapped reader's data and returns the offset the
+// tail starts at, which is 0 if there are size bytes or less.
+func (r *TailReader) Fill() (int64, error) {
+	if !r.done {
+		r.done = true
+		readBuf := make([]byte, readerBufferSize)
+		for {
+			n, err := r.wrapped.Read(readBuf)
+			r.add(readBuf[:n])
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				r.err = err
+				return 0, err
+			}
+		}
+		r.out = make([]byte, 0, len(r.ring))
+		r.out = append(r.out, r.ring[r.next:]...)
+		r.out = append(r.out, r.ring[:r.next]...)
+		r.ring = nil
+	}
+	return r.total - int64(len(r.out)), r.err
+}
+
+func (r *TailReader) add(data []byte) {
+	r.total += int64(len(data))
+	if room := r.size - int64(len(r.ring)); room > 0 {
+		take := len(data)
+		if int64(take) > room {
+			take = int(room)
+		}
+		r.ring = append(r.ring, data[:take]...)
+		data = data[take:]
+	}
+	for len(data) > 0 {
+		n := copy(r.ring[r.next:], data)
+		data = data[n:]
+		r.next = (r.next + n) % len(r.ring)
+	}
+}
+
+func (r *TailReader) Read(p []byte) (int, error) {
+	if _, err := r.Fill(); err != nil {
+		return 0, err
+	}
+	if len(r.out) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// TrimEndReader yields all but the last trim bytes of the wrapped reader,
+// like head -c -N. The last trim bytes read are held back until either more
+// data or the end of the wrapped reader shows whether they are at the end.
+type TrimEndReader struct {
+	wrapped io.Reader
+	trim    int
+	readBuf []byte
+	held    []byte
+	err     error
+}
+
+func NewTrimEndReader(reader io.Reader, trim int64) *TrimEndReader {
+	return &TrimEndReader{
+		wrapped: reader,
+		trim:    int(trim),
+		readBuf: make([]byte, readerBufferSize),
+	}
+}
+
+func (r *TrimEndReader) Read(p []byte) (int, error) {
+	for len(r.held) <= r.trim && r.err == nil {
+		n, err := r.wrapped.Read(r.readBuf)
+		r.held = append(r.held, r.readBuf[:n]...)
+		r.err = err
+	}
+	if available := len(r.held) - r.trim; available > 0 {
+		n := copy(p, r.held[:available])
+		r.held = r.held[n:]
+		return n, nil
+	}
+	return 0, r.err
+}
@@ -0,0 +1,96 @@
+package input
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/options"
+)
+
+func Test_GetInput_EndRelative(t *testing.T) {
+	data := "0123456789abcdefghij"
+	f, err := ioutil.TempFile("", "hax_tail")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(data)
+	f.Close()
+
+	type testCase struct {
+		offset         int64
+		limit          int64
+		trimEnd        int64
+		expected       string
+		expectedOffset int64
+	}
+	cases := []testCase{
+		{-5, math.MaxInt64, 0, "fghij", 15},
+		{-5, 2, 0, "fg", 15},
+		{-50, math.MaxInt64, 0, data, 0},
+		{-8, math.MaxInt64, 3, "cdefg", 12},
+		{-3, math.MaxInt64, 5, "", 17},
+		{4, math.MaxInt64, 10, "456789", 4},
+		{0, math.MaxInt64, 18, "01", 0},
+		{0, math.MaxInt64, 30, "", 0},
+		{2, 3, 10, "234", 2},
+		{2, 10, 15, "234", 2},
+	}
+	for _, c := range cases {
+		// raw file seeks, the same data as a string or base64 file is read through
+		for _, inOpts := range []options.Options{
+			{Filename: f.Name(), InputMode: options.Raw},
+			{InputData: data, InputMode: options.Raw},
+			{InputData: "MDEyMzQ1Njc4OWFiY2RlZmdoaWo=", InputMode: options.Base64},
+		} {
+			opts := inOpts
+			opts.Offset, opts.Limit, opts.TrimEnd = c.offset, c.limit, c.trimEnd
+			reader, closer, _, err := GetInput(&opts)
+			if err != nil {
+				t.Errorf("offset: %d, trim: %d, file: %q, unexpected err: %v", c.offset, c.trimEnd, opts.Filename, err)
+				continue
+			}
+			result, err := ioutil.ReadAll(io.LimitReader(reader, opts.Limit))
+			if closer != nil {
+				closer.Close()
+			}
+			if err != nil {
+				t.Errorf("offset: %d, trim: %d, file: %q, unexpected read err: %v", c.offset, c.trimEnd, opts.Filename, err)
+			}
+			if string(result) != c.expected {
+				t.Errorf("offset: %d, trim: %d, file: %q, expected: %q, got: %q", c.offset, c.trimEnd, opts.Filename, c.expected, result)
+			}
+			if opts.Offset != c.expectedOffset {
+				t.Errorf("offset: %d, trim: %d, file: %q, expected resolved offset: %d, got: %d",
+					c.offset, c.trimEnd, opts.Filename, c.expectedOffset, opts.Offset)
+			}
+		}
+	}
+}
+
+func Test_TailReader(t *testing.T) {
+	// small reads so the ring buffer wraps around several times
+	data := strings.Repeat("abcdefghijklmnopqrstuvwxyz", 40)
+	for _, size := range []int64{1, 7, 26, 100, 1040, 5000} {
+		tail := NewTailReader(io.MultiReader(strings.NewReader(data[:333]), strings.NewReader(data[333:])), size)
+		start, err := tail.Fill()
+		if err != nil {
+			t.Errorf("size: %d, unexpected err: %v", size, err)
+		}
+		expectedStart := int64(len(data)) - size
+		if expectedStart < 0 {
+			expectedStart = 0
+		}
+		if start != expectedStart {
+			t.Errorf("size: %d, expected start: %d, got: %d", size, expectedStart, start)
+		}
+		result, _ := ioutil.ReadAll(tail)
+		if string(result) != data[expectedStart:] {
+			t.Errorf("size: %d, expected: %q, got: %q", size, data[expectedStart:], result)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/jcuga/hax/commands"
 	"github.com/jcuga/hax/eval"
 	"github.com/jcuga/hax/input"
 	"github.com/jcuga/hax/options"
@@ -33,6 +34,7 @@ func main() {
 	flag.StringVar(&rawOpts.Offset, "o", "", "")
 	flag.StringVar(&rawOpts.Limit, "limit", "", "Input limit in bytes (default no limit).")
 	flag.StringVar(&rawOpts.Limit, "l", "", "")
+	flag.Var((*stringList)(&rawOpts.Ranges), "range", "Input range of start:end, ex: 0x100:0x200, -512: or 0x40:+16 (+N is a length).")
 
 	// Customize display mode output:
 	// TODO: don't have a default, then default based on output mode if not specified.
@@ -51,6 +53,18 @@ func main() {
 	flag.BoolVar(&rawOpts.Yes, "yes", false, "Auto-answer yes to any prompts.") // TODO: remember to add to custom usage output.
 	flag.BoolVar(&rawOpts.Yes, "y", false, "")
 
+	flag.StringVar(&rawOpts.Decompress, "decompress", "", "Decompress input first: gzip, zlib, bzip2, deflate or auto (by magic bytes).")
+	flag.StringVar(&rawOpts.Level, "level", "", "Compression level for gzip, zlib and deflate output: 0 (none) to 9 (best), default 6.")
+	flag.StringVar(&rawOpts.BitOrder, "bit-order", "", "Order of binary digits: msb (default) or lsb first.")
+	flag.StringVar(&rawOpts.Separator, "sep", "", "Separator between decimal/octal list items (default \", \" for dec, \" \" for oct).")
+	flag.BoolVar(&rawOpts.SignedBytes, "signed", false, "Output decimal/octal list items as signed -128 to 127.")
+	flag.StringVar(&rawOpts.VarName, "var-name", "", "Variable name for source code output (default based on --file name).")
+	flag.BoolVar(&rawOpts.PlusAsSpace, "plus-space", false, "Url encoding uses '+' for spaces (and decodes '+' as space).")
+	flag.StringVar(&rawOpts.FillByte, "fill", "", "Byte to fill gaps between ihex/srec input records (default 0xFF).")
+	flag.BoolVar(&rawOpts.Sparse, "sparse", false, "Skip gaps between ihex/srec input records and report each segment to stderr.")
+	flag.StringVar(&rawOpts.BaseAddress, "base-address", "", "Address of the first byte for ihex/srec (input default first record, output default 0).")
+	flag.BoolVar(&rawOpts.NoRangeCheck, "no-range-check", false, "Keep low 8 bits of decimal/octal input values outside of -128 to 255 instead of erroring.")
+
 	flag.BoolVar(&rawOpts.Display.HideZerosBytes, "hide-zeros", false, "Hide/leave-blank all zero bytes in hexedit display.") // TODO: remember to add to custom usage output.
 	flag.BoolVar(&rawOpts.Display.HideZerosBytes, "hide", false, "")
 
@@ -76,6 +90,14 @@ func main() {
 		fmt.Fprintf(w, "\t-n, --%s\t%s\n", f.Name, f.Usage)
 		f = flag.Lookup("limit")
 		fmt.Fprintf(w, "\t-l, --%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("range")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		fmt.Fprintf(w, "\t\t\tNegative offset, limit or range values are relative to the end of the input.\n")
+		fmt.Fprintf(w, "\t\t\t--range can be repeated or comma separated. Display output labels each range,\n")
+		fmt.Fprintf(w, "\t\t\tother outputs and commands get the ranges' data one after another.\n")
+		f = flag.Lookup("decompress")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		fmt.Fprintf(w, "\t\t\tOffset and limit are of the decompressed data.\n")
 
 		fmt.Fprintf(w, "\nOutput Options:\n")
 		f = flag.Lookup("output")
@@ -93,16 +115,63 @@ func main() {
 		f = flag.Lookup("pretty")
 		fmt.Fprintf(w, "\t-y, --%s\t%s\n", f.Name, f.Usage)
 
+		fmt.Fprintf(w, "\nOptions for specific I/O modes:\n")
+		f = flag.Lookup("bit-order")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("sep")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("signed")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("no-range-check")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("var-name")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("plus-space")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("base-address")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("fill")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("sparse")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+		f = flag.Lookup("level")
+		fmt.Fprintf(w, "\t--%s\t%s\n", f.Name, f.Usage)
+
 		fmt.Fprintf(w, "\nI/O Modes:\n")
+		fmt.Fprintf(w, "  * auto\tInput only, detects the mode from the start of the data. Notes the mode on stderr\n")
+		fmt.Fprintf(w, "\t\twhen the data could be another mode.\n")
 		fmt.Fprintf(w, "  * r, raw\tRaw bytes.\n")
 		fmt.Fprintf(w, "  * h, hex\tHex string.\n")
-		fmt.Fprintf(w, "  * b, base64\tBase64 string.\n")
+		fmt.Fprintf(w, "  * b, base64\tBase64 string. As input, any of the variants below are detected and accepted.\n")
+		fmt.Fprintf(w, "  * b64url, b64raw, b64urlraw\tBase64 with URL safe alphabet, without padding, or both (ex: JWTs).\n")
+		fmt.Fprintf(w, "  * b64mime, base64-mime\tBase64 wrapped to 76 char lines ending with \\r\\n.\n")
+		fmt.Fprintf(w, "  * b32, base32 and b32hex, base32hex\tBase32 string with standard or extended hex alphabet.\n")
+		fmt.Fprintf(w, "  * a85, ascii85 and a85d, ascii85-delim\tAscii85 string, without or with <~ ~> delimiters.\n")
+		fmt.Fprintf(w, "\t\tAs input, the delimiters are optional for both.\n")
+		fmt.Fprintf(w, "  * z85\tZeroMQ Z85 string. Data must be a multiple of 4 bytes.\n")
+		fmt.Fprintf(w, "  * d, display\tHex editor display (default output). As input, parses it back into bytes.\n")
+		fmt.Fprintf(w, "  * bin, binary\tBinary digits, 8 per byte. Input ignores whitespace, ',', '_' and 0b prefixes.\n")
+		fmt.Fprintf(w, "\t\tSee --bit-order for msb or lsb first.\n")
+		fmt.Fprintf(w, "  * xxd, hexdump-c (hd), od\tSame as output of xxd, hexdump -C and od. As input, like xxd -r.\n")
+		fmt.Fprintf(w, "  * dec, dec-list and oct, oct-list\tDecimal or octal byte lists, ex: \"72, 101\" or \"0110 0145\".\n")
+		fmt.Fprintf(w, "\t\tInput accepts -128 to 255. See --sep, --signed and --no-range-check.\n")
+		fmt.Fprintf(w, "  * c (xxd-i), go, py, rs, java\tOutput only, source code declaration of the bytes. See --var-name.\n")
+		fmt.Fprintf(w, "  * esc, c-string\tC/Python string literal escapes. As input, quotes are optional and\n")
+		fmt.Fprintf(w, "\t\tadjacent \"literals\" are joined. Output with --width wraps into quoted lines.\n")
+		fmt.Fprintf(w, "  * url, percent\tURL percent-encoding, ex: a%%20b. See --plus-space.\n")
+		fmt.Fprintf(w, "  * ihex, srec\tIntel HEX and Motorola S-records. Output record length is --width (default 16).\n")
+		fmt.Fprintf(w, "\t\tSee --base-address, --fill and --sparse.\n")
+		fmt.Fprintf(w, "  * gzip, zlib, deflate\tOutput only, compressed bytes. See --level. Unless --quiet, the\n")
+		fmt.Fprintf(w, "\t\tcompressed size and ratio are written to stderr.\n")
 
 		fmt.Fprintf(w, "\nNote:\n")
 		fmt.Fprintf(w, "  * If no --file or --str set, will get input from stdin.\n")
 		fmt.Fprintf(w, "  * For any numeric args (ex: limit, offset, etc), values starting with:\n")
 		fmt.Fprintf(w, "      '0', '0x', '\\x', or, 'x' are parsed as hex instead of decimal.\n")
 		fmt.Fprintf(w, "    Same goes if value contains A-F or a-f.\n")
+		fmt.Fprintf(w, "  * Offset, limit, range and calc expressions can use the input's size and read numbers\n")
+		fmt.Fprintf(w, "    from it with [offset:type], type is u8-u64 or i8-i64 with le (default) or be.\n")
+		fmt.Fprintf(w, "    ex: --offset '[0x3C:u32le]' --limit 'size - 0x200'. Needs --file or --str input.\n")
 
 		fmt.Fprintf(w, "\nTODO: optional commands like conv to num, str, unicode, binary, math, etc.\n")
 
@@ -113,9 +182,20 @@ func main() {
 	}
 
 	flag.Parse()
+	os.Exit(run(rawOpts))
+}
+
+// run does everything after parsing flags and gives the exit code, so that
+// deferred cleanup happens before exiting.
+func run(rawOpts options.RawOptions) int {
+	// only reads the input if an expression uses its size or data
+	exprEnv := input.NewExprEnv(rawOpts)
+	defer exprEnv.Close()
+	rawOpts.ExprEnv = exprEnv
 
 	cmd := options.NoCommand
 	cmdArgs := []string{}
+	errExitCode := 1
 	if flag.NArg() > 0 {
 		args := flag.Args()
 		cmdArgs = args[1:]
@@ -125,16 +205,16 @@ func main() {
 		case "calc", "eval":
 			cmd = options.Calc
 			expression := strings.Join(cmdArgs, "")
-			val, err := eval.EvalExpression(expression)
+			val, err := eval.EvalExpressionEnv(expression, exprEnv)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "%v\n", err)
-				os.Exit(1)
+				return 1
 			}
 			if eval.DisplayEvalResult(val); err == nil {
-				os.Exit(0)
+				return 0
 			} else {
 				fmt.Fprintf(os.Stderr, "%v\n", err)
-				os.Exit(1)
+				return 1
 			}
 		case "strings", "str", "s":
 			cmd = options.Strings
@@ -144,22 +224,37 @@ func main() {
 			cmd = options.CountBytes
 		case "search", "find":
 			cmd = options.Search
+			// like grep: 1 means no matches, so errors are 2
+			errExitCode = 2
+		case "replace", "sub":
+			cmd = options.Replace
+		case "patch":
+			cmd = options.Patch
 		default:
 			fmt.Fprintf(os.Stderr, "Unrecognized command: %q\n", args[0])
-			os.Exit(1)
+			return 1
 		}
 	}
 
 	opts, err := options.New(rawOpts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+		return errExitCode
+	}
+
+	// patch modifies --file directly instead of reading input
+	if cmd == options.Patch {
+		if err := commands.Patch(os.Stdout, opts, cmdArgs); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return errExitCode
+		}
+		return 0
 	}
 
-	inReader, inCloser, isStdin, err := input.GetInput(opts)
+	inReader, inCloser, isStdin, err := input.GetInput(&opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+		return errExitCode
 	}
 	if inCloser != nil {
 		defer inCloser.Close()
@@ -167,9 +262,13 @@ func main() {
 
 	ioInfo := getIOInfo(isStdin, &opts)
 	if err := output.Output(os.Stdout, inReader, ioInfo, opts, cmd, cmdArgs); err != nil {
+		if err == commands.ErrNoMatches {
+			return 1
+		}
 		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+		return errExitCode
 	}
+	return 0
 }
 
 func getIOInfo(inputIsStdin bool, opts *options.Options) options.IOInfo {
@@ -184,3 +283,15 @@ func getIOInfo(inputIsStdin bool, opts *options.Options) options.IOInfo {
 	info.OutputPretty = !info.StdoutIsPipe || opts.Display.Pretty
 	return info
 }
+
+// stringList is a flag that can be given more than once, ex: --range 0:16 --range -16:
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
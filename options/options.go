@@ -22,7 +22,49 @@ const (
 	HexList   // List of hex bytes that can be used in an array literal: "0xAB, 0xCD, 0xEF"
 	HexAscii  // mix of ascii printables and \x escaped hex
 	Base64
+	Base64Url    // URL and filename safe alphabet, "-" and "_" instead of "+" and "/"
+	Base64Raw    // no "=" padding
+	Base64UrlRaw // URL safe alphabet without padding, ex: JWTs
+	Base64Mime   // wrapped to 76 char lines with "\r\n" like MIME email
 	Display
+	Xxd              // same as xxd's default output
+	HexdumpCanonical // same as hexdump -C
+	Od               // same as od's default output
+	Binary           // 8 binary digits per byte, ex: "01000001 01000010"
+	Base32
+	Base32Hex // base32 with the "extended hex" alphabet from RFC 4648
+	Ascii85
+	Ascii85Delimited  // ascii85 wrapped in Adobe's "<~" and "~>"
+	Z85               // ZeroMQ's base85 variant
+	DecList           // List of decimal bytes, ex: "72, 101, 108"
+	OctList           // List of octal bytes, ex: "0110 0145 0154"
+	CSource           // C array declaration like xxd -i
+	GoSource          // Go []byte declaration
+	PythonSource      // Python bytes literal
+	RustSource        // Rust [u8; N] static
+	JavaSource        // Java byte[] with signed values
+	CString           // C/Python string literal escapes, ex: "Hi\n\x00"
+	UrlEncoded        // URL percent-encoding, ex: "a%20b%2F"
+	IntelHex          // Intel HEX records, ex: ":0300300002337A1E"
+	SRecord           // Motorola S-records, ex: "S1130000285F..."
+	GzipCompressed    // output only, gzip compressed bytes
+	ZlibCompressed    // output only, zlib compressed bytes
+	DeflateCompressed // output only, raw deflate stream
+	Auto              // input only, detect the mode from the start of the data
+)
+
+// Compression of input data to decompress before parsing the input mode.
+type Compression int
+
+const (
+	NoCompression Compression = iota
+	Gzip
+	Zlib
+	Bzip2
+	Deflate // raw deflate stream without a zlib or gzip header
+	// AutoCompression picks gzip, zlib or bzip2 by their magic bytes, or
+	// none if the data doesn't start with any of them.
+	AutoCompression
 )
 
 const (
@@ -32,6 +74,8 @@ const (
 	Strings
 	StringsUtf8
 	Search
+	Replace
+	Patch
 )
 
 func CommandToString(cmd Command) string {
@@ -48,11 +92,100 @@ func CommandToString(cmd Command) string {
 		return "utf-8"
 	case Search:
 		return "search"
+	case Replace:
+		return "replace"
+	case Patch:
+		return "patch"
+	default:
+		return "unknown"
+	}
+}
+
+// IOModeToString gets the name of a mode as used by --input and --output.
+func IOModeToString(mode IOMode) string {
+	switch mode {
+	case Raw:
+		return "raw"
+	case Hex:
+		return "hex"
+	case HexString:
+		return "hex-string"
+	case HexList:
+		return "hex-list"
+	case HexAscii:
+		return "hex-ascii"
+	case Base64:
+		return "base64"
+	case Base64Url:
+		return "base64-url"
+	case Base64Raw:
+		return "base64-raw"
+	case Base64UrlRaw:
+		return "base64-url-raw"
+	case Base64Mime:
+		return "base64-mime"
+	case Display:
+		return "display"
+	case Xxd:
+		return "xxd"
+	case HexdumpCanonical:
+		return "hexdump-c"
+	case Od:
+		return "od"
+	case Binary:
+		return "binary"
+	case Base32:
+		return "base32"
+	case Base32Hex:
+		return "base32hex"
+	case Ascii85:
+		return "ascii85"
+	case Ascii85Delimited:
+		return "ascii85-delim"
+	case Z85:
+		return "z85"
+	case DecList:
+		return "dec-list"
+	case OctList:
+		return "oct-list"
+	case CSource:
+		return "c"
+	case GoSource:
+		return "go"
+	case PythonSource:
+		return "python"
+	case RustSource:
+		return "rust"
+	case JavaSource:
+		return "java"
+	case CString:
+		return "c-string"
+	case UrlEncoded:
+		return "url"
+	case IntelHex:
+		return "ihex"
+	case SRecord:
+		return "srec"
+	case GzipCompressed:
+		return "gzip"
+	case ZlibCompressed:
+		return "zlib"
+	case DeflateCompressed:
+		return "deflate"
+	case Auto:
+		return "auto"
 	default:
 		return "unknown"
 	}
 }
 
+// Range of the input data, same as Options.Offset, Limit and TrimEnd.
+type Range struct {
+	Offset  int64
+	Limit   int64
+	TrimEnd int64
+}
+
 type DisplayOptions struct {
 	Width          int
 	SubWidth       int // add space after every SubWidth bytes
@@ -68,11 +201,49 @@ type Options struct {
 	InputData  string
 	InputMode  IOMode
 	OutputMode IOMode
-	Offset     int64
-	Limit      int64
-	Display    DisplayOptions
+	// Offset to start at, negative is relative to the end of the data like tail -c.
+	Offset int64
+	// OffsetGiven is whether --offset or --range was set, rather than
+	// Offset being the default of 0.
+	OffsetGiven bool
+	Limit       int64
+	// TrimEnd is the number of bytes to stop before the end of the data, from
+	// a negative --limit or --range end.
+	TrimEnd int64
+	// Ranges when more than one --range is given, otherwise Offset, Limit and
+	// TrimEnd are used. Once input is read these are absolute offsets and
+	// the data is all of the ranges one after another. Lengths are exact,
+	// except streamed ranges only get theirs once the end of the data is read.
+	Ranges  []Range
+	Display DisplayOptions
 	// Yes is whether to auto-answer y/yes to any prompts
 	Yes bool
+	// LsbFirst is whether binary digits go from least to most significant bit
+	LsbFirst bool
+	// Separator between decimal/octal list items. Empty means the mode's default.
+	// On input, these chars are ignored in addition to whitespace, commas and brackets.
+	Separator string
+	// SignedBytes outputs decimal/octal list items as -128 to 127 instead of 0 to 255.
+	SignedBytes bool
+	// NoRangeCheck keeps the low 8 bits of decimal/octal input values instead
+	// of erroring when outside of -128 to 255.
+	NoRangeCheck bool
+	// VarName for source code literal output. Empty means based on Filename.
+	VarName string
+	// PlusAsSpace is whether url encoding uses '+' for spaces like html forms.
+	PlusAsSpace bool
+	// FillByte fills gaps between Intel HEX/S-record input records.
+	FillByte byte
+	// Sparse skips gaps between Intel HEX/S-record input records instead of
+	// filling them and reports each contiguous segment.
+	Sparse bool
+	// BaseAddress of the first byte of Intel HEX/S-record data, -1 if not set.
+	// On input, defaults to the first record's address, on output to 0.
+	BaseAddress int64
+	// Decompress input before parsing it, offset and limit are of the decompressed data.
+	Decompress Compression
+	// CompressionLevel for gzip/zlib/deflate output, 0 to 9 or -1 for the default.
+	CompressionLevel int
 }
 
 // RawOptions are pre-parsed, pre-validated version of options.
@@ -86,9 +257,28 @@ type RawOptions struct {
 	OutputMode string
 	Offset     string
 	Limit      string
-	Display    RawDisplayOptions
+	// Ranges of start:end, an alternative to Offset and Limit. Each can be a
+	// comma separated list of ranges too.
+	Ranges  []string
+	Display RawDisplayOptions
 	// Yes is whether to auto-answer y/yes to any prompts
 	Yes bool
+	// BitOrder of binary digits: msb (default) or lsb first
+	BitOrder string
+	// Separator between decimal/octal list items, supports "\n" and "\t" escapes.
+	Separator    string
+	SignedBytes  bool
+	NoRangeCheck bool
+	VarName      string
+	PlusAsSpace  bool
+	FillByte     string
+	Sparse       bool
+	BaseAddress  string
+	Decompress   string
+	Level        string
+	// ExprEnv gives --offset, --limit and --range expressions the input's
+	// size and data, ex: "size-0x200" or "[0x3C:u32le]". Can be nil.
+	ExprEnv eval.Env
 }
 
 // RawDisplayOptions are pre-parsed, pre-validated options.\
@@ -123,7 +313,49 @@ func parseInputMode(mode string) (IOMode, error) {
 		return HexList, nil
 	case "base64", "b64", "b":
 		return Base64, nil
-	// NOTE: not valid input modes: Display, Strings
+	case "base64-url", "base64url", "b64url":
+		return Base64Url, nil
+	case "base64-raw", "base64raw", "b64raw":
+		return Base64Raw, nil
+	case "base64-url-raw", "base64urlraw", "b64urlraw":
+		return Base64UrlRaw, nil
+	case "base64-mime", "base64mime", "b64mime":
+		return Base64Mime, nil
+	case "display", "d":
+		return Display, nil
+	case "xxd":
+		return Xxd, nil
+	case "hexdump-c", "hd":
+		return HexdumpCanonical, nil
+	case "od":
+		return Od, nil
+	case "bin", "binary":
+		return Binary, nil
+	case "base32", "b32":
+		return Base32, nil
+	case "base32hex", "b32hex":
+		return Base32Hex, nil
+	case "ascii85", "a85":
+		return Ascii85, nil
+	case "ascii85-delim", "a85-delim", "a85d":
+		return Ascii85Delimited, nil
+	case "z85":
+		return Z85, nil
+	case "dec-list", "declist", "dec", "decimal":
+		return DecList, nil
+	case "oct-list", "octlist", "oct", "octal":
+		return OctList, nil
+	case "c-string", "c-str", "cstr", "escaped", "esc":
+		return CString, nil
+	case "url", "url-encoded", "percent", "pct":
+		return UrlEncoded, nil
+	case "ihex", "intel-hex":
+		return IntelHex, nil
+	case "srec", "s-record", "s19":
+		return SRecord, nil
+	case "auto":
+		return Auto, nil
+	// NOTE: not valid input modes: Strings
 	default:
 		return -1, fmt.Errorf("Not a valid input mode: %q.", mode)
 	}
@@ -144,13 +376,142 @@ func parseOutputMode(mode string) (IOMode, error) {
 		return HexAscii, nil
 	case "base64", "b64", "b":
 		return Base64, nil
+	case "base64-url", "base64url", "b64url":
+		return Base64Url, nil
+	case "base64-raw", "base64raw", "b64raw":
+		return Base64Raw, nil
+	case "base64-url-raw", "base64urlraw", "b64urlraw":
+		return Base64UrlRaw, nil
+	case "base64-mime", "base64mime", "b64mime":
+		return Base64Mime, nil
 	case "display", "d":
 		return Display, nil
+	case "xxd":
+		return Xxd, nil
+	case "hexdump-c", "hd":
+		return HexdumpCanonical, nil
+	case "od":
+		return Od, nil
+	case "bin", "binary":
+		return Binary, nil
+	case "base32", "b32":
+		return Base32, nil
+	case "base32hex", "b32hex":
+		return Base32Hex, nil
+	case "ascii85", "a85":
+		return Ascii85, nil
+	case "ascii85-delim", "a85-delim", "a85d":
+		return Ascii85Delimited, nil
+	case "z85":
+		return Z85, nil
+	case "dec-list", "declist", "dec", "decimal":
+		return DecList, nil
+	case "oct-list", "octlist", "oct", "octal":
+		return OctList, nil
+	case "c-string", "c-str", "cstr", "escaped", "esc":
+		return CString, nil
+	case "url", "url-encoded", "percent", "pct":
+		return UrlEncoded, nil
+	case "ihex", "intel-hex":
+		return IntelHex, nil
+	case "srec", "s-record", "s19":
+		return SRecord, nil
+	case "c", "xxd-i", "c-src":
+		return CSource, nil
+	case "go", "go-src":
+		return GoSource, nil
+	case "python", "py", "py-src":
+		return PythonSource, nil
+	case "rust", "rs", "rs-src":
+		return RustSource, nil
+	case "java", "java-src":
+		return JavaSource, nil
+	case "gzip", "gz":
+		return GzipCompressed, nil
+	case "zlib":
+		return ZlibCompressed, nil
+	case "deflate":
+		return DeflateCompressed, nil
 	default:
 		return -1, fmt.Errorf("Not a valid output mode: %q.", mode)
 	}
 }
 
+// splitTopLevel splits value on sep where it isn't inside brackets or
+// parentheses, ex: the ',' and ':' in "[0x3C:u32le]:+16,-16:".
+func splitTopLevel(value string, sep rune) []string {
+	depth := 0
+	parts := []string{}
+	partStart := 0
+	for i, c := range value {
+		switch c {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, value[partStart:i])
+				partStart = i + 1
+			}
+		}
+	}
+	return append(parts, value[partStart:])
+}
+
+// parseRange parses a --range value of start:end. Start defaults to 0 and
+// end to the end of the data. Either can be negative to be relative to the
+// end of the data, or end can be +N for N bytes after start.
+// Ex: 0x100:0x200, -512: or 0x40:+16.
+func parseRange(value string, env eval.Env) (Range, error) {
+	r := Range{Limit: math.MaxInt64}
+	parts := splitTopLevel(value, ':')
+	if len(parts) != 2 {
+		return r, fmt.Errorf("Invalid --range value %q, expect start:end, ex: 0x100:0x200, -512: or 0x40:+16", value)
+	}
+	startValue, endValue := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+	start := int64(0)
+	if len(startValue) > 0 {
+		parsedStart, err := eval.EvalExpressionEnv(startValue, env)
+		if err != nil {
+			return r, fmt.Errorf("Failed to parse --range start %q, error: %v", startValue, err)
+		}
+		start = parsedStart
+	}
+	r.Offset = start
+	if len(endValue) == 0 {
+		return r, nil
+	}
+
+	isLength := strings.HasPrefix(endValue, "+")
+	end, err := eval.EvalExpressionEnv(strings.TrimPrefix(endValue, "+"), env)
+	if err != nil {
+		return r, fmt.Errorf("Failed to parse --range end %q, error: %v", endValue, err)
+	}
+	switch {
+	case isLength:
+		if end < 0 {
+			return r, fmt.Errorf("Invalid --range value %q, +length must be >= 0", value)
+		}
+		r.Limit = end
+	case end < 0:
+		if start < 0 && end < start {
+			return r, fmt.Errorf("Invalid --range value %q, end is before start", value)
+		}
+		r.TrimEnd = -end
+	case start < 0:
+		// would need the size of the data to know the limit
+		return r, fmt.Errorf("Invalid --range value %q, end must be negative or +length when start is negative", value)
+	default:
+		if end < start {
+			return r, fmt.Errorf("Invalid --range value %q, end is before start", value)
+		}
+		r.Limit = end - start
+	}
+	return r, nil
+}
+
 // TODO: any error text here needs arg names to update those in main if they've changed during development!
 func New(rawOpts RawOptions) (Options, error) {
 
@@ -194,12 +555,9 @@ func New(rawOpts RawOptions) (Options, error) {
 	}
 
 	opts.Offset = 0
+	opts.OffsetGiven = len(rawOpts.Offset) > 0 || len(rawOpts.Ranges) > 0
 	if len(rawOpts.Offset) > 0 {
-		if parsedOffset, err := eval.EvalExpression(rawOpts.Offset); err == nil {
-			if parsedOffset < 0 {
-				return opts, fmt.Errorf(
-					"Invalid --offset/-n value %q, must be >= 0 ", rawOpts.Offset)
-			}
+		if parsedOffset, err := eval.EvalExpressionEnv(rawOpts.Offset, rawOpts.ExprEnv); err == nil {
 			opts.Offset = parsedOffset
 		} else {
 			return opts, fmt.Errorf(
@@ -209,13 +567,12 @@ func New(rawOpts RawOptions) (Options, error) {
 
 	opts.Limit = math.MaxInt64
 	if len(rawOpts.Limit) > 0 {
-		if parsedLimit, err := eval.EvalExpression(rawOpts.Limit); err == nil {
-			if parsedLimit < 0 {
-				return opts, fmt.Errorf(
-					"Invalid --limit/-l value %q, must be >= 0 ", rawOpts.Limit)
-			}
+		if parsedLimit, err := eval.EvalExpressionEnv(rawOpts.Limit, rawOpts.ExprEnv); err == nil {
 			if parsedLimit == 0 {
 				opts.Limit = math.MaxInt64
+			} else if parsedLimit < 0 {
+				// stop this many bytes before the end
+				opts.TrimEnd = -parsedLimit
 			} else {
 				opts.Limit = parsedLimit
 			}
@@ -225,6 +582,25 @@ func New(rawOpts RawOptions) (Options, error) {
 		}
 	}
 
+	if len(rawOpts.Ranges) > 0 {
+		if len(rawOpts.Offset) > 0 || len(rawOpts.Limit) > 0 {
+			return opts, fmt.Errorf("Can't use --range with --offset or --limit")
+		}
+		for _, rawRanges := range rawOpts.Ranges {
+			for _, value := range splitTopLevel(rawRanges, ',') {
+				r, err := parseRange(value, rawOpts.ExprEnv)
+				if err != nil {
+					return opts, err
+				}
+				opts.Ranges = append(opts.Ranges, r)
+			}
+		}
+		if len(opts.Ranges) == 1 {
+			opts.Offset, opts.Limit, opts.TrimEnd = opts.Ranges[0].Offset, opts.Ranges[0].Limit, opts.Ranges[0].TrimEnd
+			opts.Ranges = nil
+		}
+	}
+
 	if rawOpts.Display.Width == "" {
 		if opts.OutputMode == Display {
 			opts.Display.Width = 16
@@ -260,6 +636,81 @@ func New(rawOpts RawOptions) (Options, error) {
 		}
 	}
 
+	switch strings.ToLower(rawOpts.BitOrder) {
+	case "", "msb":
+		opts.LsbFirst = false
+	case "lsb":
+		opts.LsbFirst = true
+	default:
+		return opts, fmt.Errorf("Invalid --bit-order value %q, must be msb or lsb", rawOpts.BitOrder)
+	}
+
+	opts.Separator = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\r`, "\r").Replace(rawOpts.Separator)
+	opts.SignedBytes = rawOpts.SignedBytes
+	opts.NoRangeCheck = rawOpts.NoRangeCheck
+	opts.VarName = rawOpts.VarName
+	opts.PlusAsSpace = rawOpts.PlusAsSpace
+	opts.Sparse = rawOpts.Sparse
+
+	opts.FillByte = 0xFF
+	if len(rawOpts.FillByte) > 0 {
+		if parsedFill, err := eval.EvalExpression(rawOpts.FillByte); err == nil {
+			if parsedFill < 0 || parsedFill > 0xFF {
+				return opts, fmt.Errorf(
+					"Invalid --fill value %q, must be 0 to 0xFF", rawOpts.FillByte)
+			}
+			opts.FillByte = byte(parsedFill)
+		} else {
+			return opts, fmt.Errorf(
+				"Failed to parse --fill value %q, error: %v", rawOpts.FillByte, err)
+		}
+	}
+
+	switch strings.ToLower(rawOpts.Decompress) {
+	case "", "none":
+		opts.Decompress = NoCompression
+	case "gzip", "gz":
+		opts.Decompress = Gzip
+	case "zlib":
+		opts.Decompress = Zlib
+	case "bzip2", "bz2":
+		opts.Decompress = Bzip2
+	case "deflate":
+		opts.Decompress = Deflate
+	case "auto":
+		opts.Decompress = AutoCompression
+	default:
+		return opts, fmt.Errorf("Invalid --decompress value %q, must be gzip, zlib, bzip2, deflate or auto", rawOpts.Decompress)
+	}
+
+	opts.CompressionLevel = -1
+	if len(rawOpts.Level) > 0 {
+		if parsedLevel, err := eval.EvalExpression(rawOpts.Level); err == nil {
+			if parsedLevel < 0 || parsedLevel > 9 {
+				return opts, fmt.Errorf(
+					"Invalid --level value %q, must be 0 to 9", rawOpts.Level)
+			}
+			opts.CompressionLevel = int(parsedLevel)
+		} else {
+			return opts, fmt.Errorf(
+				"Failed to parse --level value %q, error: %v", rawOpts.Level, err)
+		}
+	}
+
+	opts.BaseAddress = -1
+	if len(rawOpts.BaseAddress) > 0 {
+		if parsedBase, err := eval.EvalExpression(rawOpts.BaseAddress); err == nil {
+			if parsedBase < 0 || parsedBase > 0xFFFFFFFF {
+				return opts, fmt.Errorf(
+					"Invalid --base-address value %q, must be 0 to 0xFFFFFFFF", rawOpts.BaseAddress)
+			}
+			opts.BaseAddress = parsedBase
+		} else {
+			return opts, fmt.Errorf(
+				"Failed to parse --base-address value %q, error: %v", rawOpts.BaseAddress, err)
+		}
+	}
+
 	if parsedPage, err := eval.EvalExpression(rawOpts.Display.PageSize); err == nil {
 		if parsedPage < 0 {
 			return opts, fmt.Errorf(
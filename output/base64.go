@@ -9,15 +9,36 @@ import (
 	"github.com/jcuga/hax/options"
 )
 
+const mimeLineWidth = 76
+
 func outputBase64(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
 	buf := make([]byte, options.OutputBufferSize)
 	bytesWritten := int64(0) // num input bytes written, NOT the number of bytes the base64 output fills.
 	var outWriter io.Writer
 	outWriter = writer
-	if opts.Display.Width > 0 { // wrap to add newlines every width bytes
+	if opts.OutputMode == options.Base64Mime {
+		// RFC 2045 limits lines to 76 chars, but allow a shorter width.
+		width := mimeLineWidth
+		if opts.Display.Width > 0 && opts.Display.Width < width {
+			width = opts.Display.Width
+		}
+		outWriter, _ = NewFixedWidthNewlineWriter(outWriter, width, "\r\n")
+	} else if opts.Display.Width > 0 { // wrap to add newlines every width bytes
 		outWriter, _ = NewFixedWidthWriter(outWriter, opts.Display.Width)
 	}
-	encoder := base64.NewEncoder(base64.StdEncoding, outWriter)
+
+	var encoding *base64.Encoding
+	switch opts.OutputMode {
+	case options.Base64Url:
+		encoding = base64.URLEncoding
+	case options.Base64Raw:
+		encoding = base64.RawStdEncoding
+	case options.Base64UrlRaw:
+		encoding = base64.RawURLEncoding
+	default:
+		encoding = base64.StdEncoding
+	}
+	encoder := base64.NewEncoder(encoding, outWriter)
 	defer func() {
 		encoder.Close() // needed to flush/encode any final, partial block of data
 	}()
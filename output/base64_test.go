@@ -0,0 +1,87 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_outputBase64(t *testing.T) {
+	type testCase struct {
+		mode     options.IOMode
+		width    int
+		data     string
+		expected string
+	}
+	data := "\xFB\xFF\xBFhi"
+	cases := []testCase{
+		{options.Base64, 0, data, "+/+/aGk="},
+		{options.Base64Url, 0, data, "-_-_aGk="},
+		{options.Base64Raw, 0, data, "+/+/aGk"},
+		{options.Base64UrlRaw, 0, data, "-_-_aGk"},
+		{options.Base64, 4, data, "+/+/\naGk="},
+		{options.Base64Mime, 0, strings.Repeat("\x00", 60), strings.Repeat("A", 76) + "\r\n" + "AAAA"},
+		{options.Base64Mime, 4, data, "+/+/\r\naGk="},
+		{options.Base64Mime, 100, strings.Repeat("\x00", 60), strings.Repeat("A", 76) + "\r\n" + "AAAA"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		opts := options.Options{OutputMode: c.mode, Limit: math.MaxInt64, Display: options.DisplayOptions{Width: c.width}}
+		err := outputBase64(&writer, input.NewFixedLengthBufferedReader(strings.NewReader(c.data)), options.IOInfo{}, opts)
+		if err != nil {
+			t.Errorf("mode: %v, unexpected err: %v", c.mode, err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("mode: %v, width: %d, unexpected output, expected: %q, got: %q", c.mode, c.width, c.expected, result)
+		}
+	}
+}
+
+func Test_Output_Base64Input(t *testing.T) {
+	type testCase struct {
+		data        string
+		expected    string
+		expectedErr string
+	}
+	cases := []testCase{
+		{"+/+/aGk=", "\xFB\xFF\xBFhi", ""},
+		{"-_-_aGk=", "\xFB\xFF\xBFhi", ""},
+		{"+/+/aGk", "\xFB\xFF\xBFhi", ""},
+		{"-_-_\r\naGk", "\xFB\xFF\xBFhi", ""},
+		{"eyJhbGciOiJIUzI1NiJ9", "{\"alg\":\"HS256\"}", ""},
+		{"aA", "h", ""},
+		{"aGk=aGk=", "hi", "illegal base64 data"},
+		{"aGkha", "", "unexpected EOF"},
+	}
+	for _, mode := range []options.IOMode{options.Base64, options.Base64UrlRaw} {
+		for _, c := range cases {
+			opts := options.Options{InputMode: mode, OutputMode: options.Raw, InputData: c.data, Limit: math.MaxInt64}
+			reader, _, _, err := input.GetInput(&opts)
+			if err != nil {
+				t.Fatalf("Failed to create input reader, error: %v", err)
+			}
+			var writer strings.Builder
+			err = Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{})
+			if c.expectedErr == "" && err != nil {
+				t.Errorf("data: %q, unexpected err: %v", c.data, err)
+			}
+			if c.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), c.expectedErr)) {
+				t.Errorf("data: %q, expected err containing: %q, got: %v", c.data, c.expectedErr, err)
+			}
+			if result := writer.String(); c.expectedErr == "" && result != c.expected {
+				t.Errorf("data: %q, unexpected result, expected: %q, got: %q", c.data, c.expected, result)
+			}
+		}
+	}
+
+	data := "\x00\x01\x80\xFF\xFB\xEFhello world"
+	for _, mode := range []options.IOMode{options.Base64, options.Base64Url, options.Base64Raw, options.Base64UrlRaw, options.Base64Mime} {
+		outOpts := options.Options{OutputMode: mode, Display: options.DisplayOptions{Width: 5}}
+		if result := outputThenInput(t, data, outOpts, options.IOInfo{StdoutIsPipe: true}, options.Base64); result != data {
+			t.Errorf("mode: %v, unexpected result, expected: %q, got: %q", mode, data, result)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+// outputBinary writes each byte as 8 binary digits separated by spaces.
+// opts.Display.Width sets bytes per line (0 is no wrapping) and SubWidth adds
+// an extra space every N bytes.
+func outputBinary(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	buf := make([]byte, options.OutputBufferSize)
+	bytesWritten := int64(0)
+	bitsBuf := bytes.Buffer{}
+
+	for {
+		var n int
+		var err error
+		// only read up to limit many bytes:
+		if opts.Limit-bytesWritten < options.OutputBufferSize {
+			n, err = reader.Read(buf[:opts.Limit-bytesWritten])
+		} else {
+			n, err = reader.Read(buf)
+		}
+
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+
+		bitsBuf.Reset()
+		for i, b := range buf[:n] {
+			count := bytesWritten + int64(i)
+			if count > 0 {
+				if opts.Display.Width > 0 && count%int64(opts.Display.Width) == 0 {
+					bitsBuf.WriteByte('\n')
+				} else if opts.Display.SubWidth > 0 && count%int64(opts.Display.SubWidth) == 0 {
+					bitsBuf.WriteString("  ")
+				} else {
+					bitsBuf.WriteByte(' ')
+				}
+			}
+			for bit := 0; bit < 8; bit++ {
+				shift := uint(7 - bit)
+				if opts.LsbFirst {
+					shift = uint(bit)
+				}
+				bitsBuf.WriteByte('0' + (b>>shift)&1)
+			}
+		}
+		writer.Write(bitsBuf.Bytes())
+
+		bytesWritten += int64(n)
+		if bytesWritten >= opts.Limit {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_outputBinary(t *testing.T) {
+	type testCase struct {
+		opts     options.Options
+		expected string
+	}
+	cases := []testCase{
+		{options.Options{}, "01000001 00000001 11110000 01000010 01000011"},
+		{options.Options{LsbFirst: true}, "10000010 10000000 00001111 01000010 11000010"},
+		{options.Options{Display: options.DisplayOptions{Width: 2}}, "01000001 00000001\n11110000 01000010\n01000011"},
+		{options.Options{Display: options.DisplayOptions{Width: 4, SubWidth: 2}}, "01000001 00000001  11110000 01000010\n01000011"},
+		{options.Options{Limit: 2}, "01000001 00000001"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		if c.opts.Limit == 0 {
+			c.opts.Limit = math.MaxInt64
+		}
+		c.opts.OutputMode = options.Binary
+		err := outputBinary(&writer, input.NewFixedLengthBufferedReader(strings.NewReader("A\x01\xF0BC")), options.IOInfo{}, c.opts)
+		if err != nil {
+			t.Errorf("Unexpected err: %v", err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("opts: %+v, unexpected output.\nExpected:\n%q\n\ngot:\n%q", c.opts, c.expected, result)
+		}
+	}
+}
+
+func Test_Output_BinaryInput(t *testing.T) {
+	type testCase struct {
+		data        string
+		lsbFirst    bool
+		expected    string
+		expectedErr string
+	}
+	cases := []testCase{
+		{"01000001 01000010", false, "AB", ""},
+		{"0b01000001, 0B01000010,\n0b0100_0011", false, "ABC", ""},
+		{"10000010 01000010", true, "AB", ""},
+		{"0100000", false, "", "Binary input of 7 digits is not a multiple of 8"},
+		{"01000001 0b2", false, "", "Invalid binary digit '2' after 8 digits"},
+	}
+	for _, c := range cases {
+		opts := options.Options{InputMode: options.Binary, OutputMode: options.Raw, InputData: c.data, Limit: math.MaxInt64, LsbFirst: c.lsbFirst}
+		reader, _, _, err := input.GetInput(&opts)
+		if err != nil {
+			t.Fatalf("Failed to create input reader, error: %v", err)
+		}
+		var writer strings.Builder
+		err = Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{})
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("data: %q, unexpected err: %v", c.data, err)
+		}
+		if c.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), c.expectedErr)) {
+			t.Errorf("data: %q, expected err containing: %q, got: %v", c.data, c.expectedErr, err)
+		}
+		if result := writer.String(); c.expectedErr == "" && result != c.expected {
+			t.Errorf("data: %q, unexpected result, expected: %q, got: %q", c.data, c.expected, result)
+		}
+	}
+
+	data := "\x00\x01\x80\xFFhello"
+	outOpts := options.Options{OutputMode: options.Binary, Display: options.DisplayOptions{Width: 3, SubWidth: 2}}
+	if result := outputThenInput(t, data, outOpts, options.IOInfo{StdoutIsPipe: true}, options.Binary); result != data {
+		t.Errorf("Unexpected result, expected: %q, got: %q", data, result)
+	}
+}
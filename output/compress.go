@@ -0,0 +1,112 @@
+package output
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jcuga/hax/commands"
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+	"github.com/jcuga/hax/util"
+)
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	wrapped io.Writer
+	count   int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.wrapped.Write(p)
+	w.count += int64(n)
+	return n, err
+}
+
+// outputCompressed streams the data through a gzip, zlib or deflate writer at
+// opts.CompressionLevel. Unless quiet, the sizes and compression ratio are
+// written to stderr so they don't end up in the compressed output.
+func outputCompressed(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	// compressed data is always binary, so same prompt as raw output without waiting to see it
+	if !ioInfo.StdoutIsPipe && !opts.Yes {
+		if !util.PromptForYes("Output may be a binary file.  See it anyway?") {
+			return nil
+		}
+	}
+	counter := &countingWriter{wrapped: writer}
+	var compressor io.WriteCloser
+	var err error
+	switch opts.OutputMode {
+	case options.GzipCompressed:
+		compressor, err = gzip.NewWriterLevel(counter, opts.CompressionLevel)
+	case options.ZlibCompressed:
+		compressor, err = zlib.NewWriterLevel(counter, opts.CompressionLevel)
+	case options.DeflateCompressed:
+		compressor, err = flate.NewWriter(counter, opts.CompressionLevel)
+	default:
+		return fmt.Errorf("Unsupported compression output mode: %v", opts.OutputMode)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to create compressor, error: %v", err)
+	}
+
+	buf := make([]byte, options.OutputBufferSize)
+	bytesRead := int64(0)
+	for {
+		var n int
+		// only read up to limit many bytes:
+		if opts.Limit-bytesRead < options.OutputBufferSize {
+			n, err = reader.Read(buf[:opts.Limit-bytesRead])
+		} else {
+			n, err = reader.Read(buf)
+		}
+
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+		if _, err := compressor.Write(buf[:n]); err != nil {
+			return fmt.Errorf("Error compressing data: %v", err)
+		}
+
+		bytesRead += int64(n)
+		if bytesRead >= opts.Limit {
+			break
+		}
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("Error compressing data: %v", err)
+	}
+
+	if !opts.Display.Quiet {
+		writeCompressionSummary(os.Stderr, options.IOModeToString(opts.OutputMode), bytesRead, counter.count)
+	}
+	return nil
+}
+
+// isCompressedOutput is whether mode is one of the compressed output modes.
+func isCompressedOutput(mode options.IOMode) bool {
+	return mode == options.GzipCompressed || mode == options.ZlibCompressed || mode == options.DeflateCompressed
+}
+
+// writeCompressionSummary writes the sizes before and after compression like
+// the count command, along with the compressed size as a percent of the original.
+func writeCompressionSummary(writer io.Writer, name string, originalLen int64, compressedLen int64) {
+	fmt.Fprintf(writer, "%d bytes", originalLen)
+	if size := commands.HumanSize(originalLen); size != "" {
+		fmt.Fprintf(writer, " (%s)", size)
+	}
+	fmt.Fprintf(writer, "\n%s: %d bytes", name, compressedLen)
+	if size := commands.HumanSize(compressedLen); size != "" {
+		fmt.Fprintf(writer, " (%s)", size)
+	}
+	if originalLen > 0 {
+		fmt.Fprintf(writer, ", %0.2f%% of original", float64(compressedLen)*100/float64(originalLen))
+	}
+	fmt.Fprintf(writer, "\n")
+}
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_outputCompressed(t *testing.T) {
+	type testCase struct {
+		mode        options.IOMode
+		compression options.Compression
+		level       int
+		offset      int64
+		limit       int64
+	}
+	data := strings.Repeat("compress me please! ", 500)
+	cases := []testCase{
+		{options.GzipCompressed, options.Gzip, -1, 0, math.MaxInt64},
+		{options.ZlibCompressed, options.Zlib, -1, 0, math.MaxInt64},
+		{options.DeflateCompressed, options.Deflate, -1, 0, math.MaxInt64},
+		{options.GzipCompressed, options.Gzip, 0, 0, math.MaxInt64},
+		{options.GzipCompressed, options.Gzip, 9, 0, math.MaxInt64},
+		{options.ZlibCompressed, options.Zlib, 1, 3, 100},
+	}
+	for _, c := range cases {
+		var writer bytes.Buffer
+		opts := options.Options{OutputMode: c.mode, CompressionLevel: c.level, Limit: c.limit,
+			Display: options.DisplayOptions{Quiet: true}}
+		reader := input.NewFixedLengthBufferedReader(strings.NewReader(data[c.offset:]))
+		if err := outputCompressed(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts); err != nil {
+			t.Errorf("mode: %v, level: %d, unexpected err: %v", c.mode, c.level, err)
+			continue
+		}
+		if c.level == 0 && writer.Len() <= len(data) {
+			t.Errorf("mode: %v, expected level 0 to be larger than original %d bytes, got: %d", c.mode, len(data), writer.Len())
+		} else if c.level != 0 && writer.Len() >= len(data)/10 {
+			t.Errorf("mode: %v, level: %d, expected repeated data to compress well, got: %d bytes", c.mode, c.level, writer.Len())
+		}
+
+		decompressed, err := input.NewDecompressReader(&writer, c.compression)
+		if err != nil {
+			t.Errorf("mode: %v, unexpected decompress err: %v", c.mode, err)
+			continue
+		}
+		result, err := ioutil.ReadAll(decompressed)
+		if err != nil {
+			t.Errorf("mode: %v, unexpected decompress err: %v", c.mode, err)
+		}
+		expected := data[c.offset:]
+		if c.limit < int64(len(expected)) {
+			expected = expected[:c.limit]
+		}
+		if string(result) != expected {
+			t.Errorf("mode: %v, unexpected round trip, expected %d bytes: %q, got %d bytes: %q",
+				c.mode, len(expected), expected, len(result), result)
+		}
+	}
+}
+
+func Test_Output_compressedToTerminal(t *testing.T) {
+	// --yes skips the binary output prompt, and no newline is added to the end
+	for _, mode := range []options.IOMode{options.GzipCompressed, options.ZlibCompressed, options.DeflateCompressed} {
+		opts := options.Options{OutputMode: mode, CompressionLevel: -1, Limit: math.MaxInt64, Yes: true,
+			Display: options.DisplayOptions{Quiet: true}}
+		var piped, terminal bytes.Buffer
+		for _, out := range []struct {
+			writer *bytes.Buffer
+			ioInfo options.IOInfo
+		}{{&piped, options.IOInfo{StdoutIsPipe: true}}, {&terminal, options.IOInfo{}}} {
+			reader := input.NewFixedLengthBufferedReader(strings.NewReader("hello hello hello"))
+			if err := Output(out.writer, reader, out.ioInfo, opts, options.NoCommand, []string{}); err != nil {
+				t.Errorf("mode: %v, unexpected err: %v", mode, err)
+			}
+		}
+		if piped.Len() == 0 || !bytes.Equal(piped.Bytes(), terminal.Bytes()) {
+			t.Errorf("mode: %v, expected same output as when piped: %q, got: %q", mode, piped.Bytes(), terminal.Bytes())
+		}
+	}
+}
+
+func Test_writeCompressionSummary(t *testing.T) {
+	type testCase struct {
+		name          string
+		originalLen   int64
+		compressedLen int64
+		expected      string
+	}
+	cases := []testCase{
+		{"gzip", 11661, 5005, "11661 bytes (11.39 KB)\ngzip: 5005 bytes (4.89 KB), 42.92% of original\n"},
+		{"zlib", 80, 20, "80 bytes\nzlib: 20 bytes, 25.00% of original\n"},
+		{"deflate", 0, 2, "0 bytes\ndeflate: 2 bytes\n"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		writeCompressionSummary(&writer, c.name, c.originalLen, c.compressedLen)
+		if result := writer.String(); result != c.expected {
+			t.Errorf("name: %s, unexpected output, expected: %q, got: %q", c.name, c.expected, result)
+		}
+	}
+}
@@ -9,6 +9,41 @@ import (
 	"github.com/jcuga/hax/options"
 )
 
+// displayRanges displays each of opts.Ranges, which are read one after
+// another from reader, under a label with its offsets and size.
+// Streamed ranges only have exact lengths once read, so each range is
+// displayed before its label is written.
+func displayRanges(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	for i, r := range opts.Ranges {
+		var rangeOut strings.Builder
+		if r.Limit > 0 {
+			rangeOpts := opts
+			rangeOpts.Offset, rangeOpts.Limit, rangeOpts.Ranges = r.Offset, r.Limit, nil
+			// rows are read a full width at a time, don't read into the next range.
+			limited := &io.LimitedReader{R: reader, N: r.Limit}
+			if err := displayHex(&rangeOut, input.NewFixedLengthBufferedReader(limited), ioInfo, rangeOpts); err != nil {
+				return err
+			}
+			r.Limit -= limited.N
+		}
+
+		if i > 0 {
+			fmt.Fprintf(writer, "\n")
+		}
+		label := fmt.Sprintf("Range %d: %X-%X (%d bytes)", i+1, r.Offset, r.Offset+r.Limit-1, r.Limit)
+		if r.Limit == 0 {
+			label = fmt.Sprintf("Range %d: %X (0 bytes)", i+1, r.Offset)
+		}
+		if ioInfo.OutputPretty {
+			fmt.Fprintf(writer, "\033[36m%s\033[0m\n", label)
+		} else {
+			fmt.Fprintf(writer, "%s\n", label)
+		}
+		io.WriteString(writer, rangeOut.String())
+	}
+	return nil
+}
+
 func displayHex(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
 	subWidthPadding := "  " // if opts.Display.SubWidth set, this amount of whitespace to pad between elements within row
 	count := int64(0)
@@ -32,7 +32,7 @@ func Test_Output_displayHex(t *testing.T) {
 		Limit:      math.MaxInt64,
 		Display:    options.DisplayOptions{Width: 16},
 	}
-	reader, closer, isStdin, err := input.GetInput(opts1)
+	reader, closer, isStdin, err := input.GetInput(&opts1)
 	ioInfo := options.IOInfo{StdoutIsPipe: true, InputIsStdin: isStdin}
 	if err != nil {
 		t.Fatalf("Failed to create input reader, error: %v", err)
@@ -69,7 +69,7 @@ func Test_Output_displayHex_HideZeroBytes(t *testing.T) {
 		Limit:      math.MaxInt64,
 		Display:    options.DisplayOptions{Width: 16, HideZerosBytes: true},
 	}
-	reader, closer, isStdin, err := input.GetInput(opts1)
+	reader, closer, isStdin, err := input.GetInput(&opts1)
 	ioInfo := options.IOInfo{StdoutIsPipe: true, InputIsStdin: isStdin}
 	if err != nil {
 		t.Fatalf("Failed to create input reader, error: %v", err)
@@ -109,7 +109,7 @@ func Benchmark_output_displayHex(b *testing.B) {
 			Limit:      math.MaxInt64,
 			Display:    options.DisplayOptions{Width: 16},
 		}
-		reader, closer, isStdin, err := input.GetInput(opts1)
+		reader, closer, isStdin, err := input.GetInput(&opts1)
 		ioInfo := options.IOInfo{InputIsStdin: isStdin}
 		if err != nil {
 			panic(fmt.Sprintf("Failed to create input reader, error: %v", err))
@@ -149,7 +149,7 @@ func Benchmark_output_displayHex_HideZeroBytes(b *testing.B) {
 			Limit:      math.MaxInt64,
 			Display:    options.DisplayOptions{Width: 16, HideZerosBytes: true},
 		}
-		reader, closer, isStdin, err := input.GetInput(opts1)
+		reader, closer, isStdin, err := input.GetInput(&opts1)
 		ioInfo := options.IOInfo{InputIsStdin: isStdin}
 		if err != nil {
 			panic(fmt.Sprintf("Failed to create input reader, error: %v", err))
@@ -165,3 +165,54 @@ func Benchmark_output_displayHex_HideZeroBytes(b *testing.B) {
 		}
 	}
 }
+
+func Test_Output_displayRanges(t *testing.T) {
+	var writer strings.Builder
+	expected := `Range 1: 0-5 (6 bytes)
+
+                0  1  2  3  4  5  6  7 
+            0: 54 68 69 73 20 69 
+                T  h  i  s     i 
+
+Range 2: 1C (0 bytes)
+
+Range 3: 28-2C (5 bytes)
+
+                0  1  2  3  4  5  6  7 
+           28: 21 21 21 21 21 
+                !  !  !  !  ! 
+`
+	opts := options.Options{
+		InputMode:  options.Raw,
+		OutputMode: options.Display,
+		InputData:  "This is only a test. Or is it? No really!!!!!",
+		Limit:      math.MaxInt64,
+		Ranges:     []options.Range{{Offset: 0, Limit: 6}, {Offset: 28, Limit: 0}, {Offset: -5, Limit: math.MaxInt64}},
+		Display:    options.DisplayOptions{Width: 8},
+	}
+	reader, _, _, err := input.GetInput(&opts)
+	if err != nil {
+		t.Fatalf("Failed to create input reader, error: %v", err)
+	}
+	if err := Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result := writer.String(); result != expected {
+		t.Fatalf("Unexpected ranges display output.\nExpected:\n%q\n\ngot:\n%q", expected, result)
+	}
+
+	// other outputs get the ranges one after another
+	writer.Reset()
+	opts.OutputMode = options.Hex
+	opts.Ranges = []options.Range{{Offset: 0, Limit: 4}, {Offset: -5, Limit: math.MaxInt64, TrimEnd: 1}}
+	reader, _, _, err = input.GetInput(&opts)
+	if err != nil {
+		t.Fatalf("Failed to create input reader, error: %v", err)
+	}
+	if err := Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result := writer.String(); result != "5468697321212121" {
+		t.Fatalf("Unexpected ranges hex output, expected: %q, got: %q", "5468697321212121", result)
+	}
+}
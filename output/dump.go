@@ -0,0 +1,146 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+// dumpFormat is how one of the classic dump tools (xxd, hexdump -C, od) lays
+// out rows of bytes so our output is byte for byte the same as theirs.
+type dumpFormat struct {
+	width int
+	// replace rows identical to the previous one with a single "*" line
+	collapseRepeats bool
+	writeRow        func(writer io.Writer, offset int64, row []byte, width int)
+	// writes whatever comes after the last row, given the offset after the last byte.
+	writeEnd func(writer io.Writer, offset int64, numBytes int64)
+}
+
+var xxdFormat = dumpFormat{
+	width: 16,
+	writeRow: func(writer io.Writer, offset int64, row []byte, width int) {
+		fmt.Fprintf(writer, "%08x: ", offset)
+		for i := 0; i < width; i++ {
+			if i < len(row) {
+				fmt.Fprintf(writer, "%02x", row[i])
+			} else {
+				fmt.Fprintf(writer, "  ")
+			}
+			if i%2 == 1 && i < width-1 {
+				fmt.Fprintf(writer, " ")
+			}
+		}
+		fmt.Fprintf(writer, "  %s\n", dumpAscii(row))
+	},
+	writeEnd: func(writer io.Writer, offset int64, numBytes int64) {},
+}
+
+var hexdumpCanonicalFormat = dumpFormat{
+	width:           16,
+	collapseRepeats: true,
+	writeRow: func(writer io.Writer, offset int64, row []byte, width int) {
+		fmt.Fprintf(writer, "%08x  ", offset)
+		for i := 0; i < width; i++ {
+			if i < len(row) {
+				fmt.Fprintf(writer, "%02x ", row[i])
+			} else {
+				fmt.Fprintf(writer, "   ")
+			}
+			if i == width/2-1 {
+				fmt.Fprintf(writer, " ")
+			}
+		}
+		fmt.Fprintf(writer, " |%s|\n", dumpAscii(row))
+	},
+	writeEnd: func(writer io.Writer, offset int64, numBytes int64) {
+		if numBytes > 0 {
+			fmt.Fprintf(writer, "%08x\n", offset)
+		}
+	},
+}
+
+// odFormat is od's default: octal offsets and 2 byte little endian words in octal.
+var odFormat = dumpFormat{
+	width:           16,
+	collapseRepeats: true,
+	writeRow: func(writer io.Writer, offset int64, row []byte, width int) {
+		fmt.Fprintf(writer, "%07o", offset)
+		for i := 0; i < len(row); i += 2 {
+			word := int(row[i])
+			if i+1 < len(row) {
+				word |= int(row[i+1]) << 8
+			}
+			fmt.Fprintf(writer, " %06o", word)
+		}
+		fmt.Fprintf(writer, "\n")
+	},
+	writeEnd: func(writer io.Writer, offset int64, numBytes int64) {
+		fmt.Fprintf(writer, "%07o\n", offset)
+	},
+}
+
+// dumpAscii is printable ascii as-is and '.' for everything else.
+func dumpAscii(row []byte) string {
+	ascii := make([]byte, len(row))
+	for i, b := range row {
+		if b >= 32 && b <= 126 {
+			ascii[i] = b
+		} else {
+			ascii[i] = '.'
+		}
+	}
+	return string(ascii)
+}
+
+func outputDump(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	var format dumpFormat
+	switch opts.OutputMode {
+	case options.Xxd:
+		format = xxdFormat
+		if opts.Display.Width > 0 {
+			format.width = opts.Display.Width // like xxd -c
+		}
+	case options.HexdumpCanonical:
+		format = hexdumpCanonicalFormat
+	case options.Od:
+		format = odFormat
+	default:
+		return fmt.Errorf("Unsupported dump output mode: %v", opts.OutputMode)
+	}
+
+	buf := make([]byte, format.width)
+	var prevRow []byte
+	collapsing := false
+	bytesWritten := int64(0)
+	for bytesWritten < opts.Limit {
+		toRead := buf
+		if opts.Limit-bytesWritten < int64(len(buf)) {
+			toRead = buf[:opts.Limit-bytesWritten]
+		}
+		n, err := reader.Read(toRead)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+		row := buf[:n]
+		if format.collapseRepeats && n == format.width && bytes.Equal(row, prevRow) {
+			if !collapsing {
+				fmt.Fprintf(writer, "*\n")
+				collapsing = true
+			}
+		} else {
+			format.writeRow(writer, opts.Offset+bytesWritten, row, format.width)
+			collapsing = false
+		}
+		prevRow = append(prevRow[:0], row...)
+		bytesWritten += int64(n)
+	}
+	format.writeEnd(writer, opts.Offset+bytesWritten, bytesWritten)
+	return nil
+}
@@ -0,0 +1,160 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_outputDump(t *testing.T) {
+	data := "hello world, 0123456789\x00\x01\xFF"
+	repeated := strings.Repeat("A", 48) + "B"
+	type testCase struct {
+		data     string
+		mode     options.IOMode
+		offset   int64
+		expected string
+	}
+	cases := []testCase{
+		{data, options.Xxd, 0, `00000000: 6865 6c6c 6f20 776f 726c 642c 2030 3132  hello world, 012
+00000010: 3334 3536 3738 3900 01ff                 3456789...
+`},
+		{data, options.HexdumpCanonical, 0, `00000000  68 65 6c 6c 6f 20 77 6f  72 6c 64 2c 20 30 31 32  |hello world, 012|
+00000010  33 34 35 36 37 38 39 00  01 ff                    |3456789...|
+0000001a
+`},
+		{data, options.Od, 0, `0000000 062550 066154 020157 067567 066162 026144 030040 031061
+0000020 032063 033065 034067 000071 177401
+0000032
+`},
+		// offsets are where the data is in the input
+		{data[3:5], options.Xxd, 3, "00000003: 6c6f                                     lo\n"},
+		{data[3:5], options.HexdumpCanonical, 3, "00000003  6c 6f                                             |lo|\n00000005\n"},
+		// repeated rows are collapsed:
+		{repeated, options.HexdumpCanonical, 0, `00000000  41 41 41 41 41 41 41 41  41 41 41 41 41 41 41 41  |AAAAAAAAAAAAAAAA|
+*
+00000030  42                                                |B|
+00000031
+`},
+		{repeated, options.Od, 0, `0000000 040501 040501 040501 040501 040501 040501 040501 040501
+*
+0000060 000102
+0000061
+`},
+		{"", options.HexdumpCanonical, 0, ""},
+		{"", options.Od, 0, "0000000\n"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		err := outputDump(&writer, input.NewFixedLengthBufferedReader(strings.NewReader(c.data)), options.IOInfo{},
+			options.Options{OutputMode: c.mode, Offset: c.offset, Limit: math.MaxInt64})
+		if err != nil {
+			t.Errorf("Unexpected err: %v", err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("mode: %v, unexpected output.\nExpected:\n%q\n\ngot:\n%q", c.mode, c.expected, result)
+		}
+	}
+}
+
+func Test_Output_DumpOutputThenInput(t *testing.T) {
+	data := "\x01\x00\x00I'm a little tea pot,\x00\x00 short and stout.\t\r\n\xFF" + strings.Repeat("\x00", 100) + "the end"
+	for _, mode := range []options.IOMode{options.Xxd, options.HexdumpCanonical, options.Od} {
+		result := outputThenInput(t, data, options.Options{OutputMode: mode}, options.IOInfo{StdoutIsPipe: true}, mode)
+		if result != data {
+			t.Errorf("mode: %v, unexpected result.\nExpected:\n%q\n\ngot:\n%q", mode, data, result)
+		}
+		// like xxd -r, data is put back at its original offset.
+		result = outputThenInput(t, data, options.Options{OutputMode: mode, Offset: 5}, options.IOInfo{StdoutIsPipe: true}, mode)
+		if expected := "\x00\x00\x00\x00\x00" + data[5:]; result != expected {
+			t.Errorf("mode: %v, unexpected result with offset.\nExpected:\n%q\n\ngot:\n%q", mode, expected, result)
+		}
+	}
+}
+
+func Test_Output_DumpInput(t *testing.T) {
+	type testCase struct {
+		mode     options.IOMode
+		dump     string
+		expected string
+	}
+	cases := []testCase{
+		// xxd -a
+		{options.Xxd, "00000000: 6162 0000  ab..\n00000004: 0000 0000  ....\n*\n00000010: 6364  cd\n", "ab" + strings.Repeat("\x00", 14) + "cd"},
+		// xxd -r fills gaps with zeros
+		{options.Xxd, "00000002: 4142  AB\n00000006: 43  C\n", "\x00\x00AB\x00\x00C"},
+		// od -A x -t x1z
+		{options.Od, "000000 68 65 6c 6c 6f  >hello<\n000005\n", "hello"},
+		// od -b
+		{options.Od, "0000000 150 145 154 154 157\n0000005\n", "hello"},
+		// od pads odd lengths, the end offset says where data really ends
+		{options.Od, "0000000 062550 066154 000157\n0000005\n", "hello"},
+	}
+	for _, c := range cases {
+		opts := options.Options{InputMode: c.mode, OutputMode: options.Raw, InputData: c.dump, Limit: math.MaxInt64}
+		reader, _, _, err := input.GetInput(&opts)
+		if err != nil {
+			t.Fatalf("Failed to create input reader, error: %v", err)
+		}
+		var writer strings.Builder
+		if err := Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{}); err != nil {
+			t.Errorf("dump: %q, unexpected err: %v", c.dump, err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("dump: %q, unexpected result, expected: %q, got: %q", c.dump, c.expected, result)
+		}
+	}
+}
+
+func Test_Output_DumpInput_odErrors(t *testing.T) {
+	type testCase struct {
+		name        string
+		dump        string
+		expectedErr string
+	}
+	cases := []testCase{
+		{
+			"-t u1",
+			"0000000 104 101 108 108 111  10\n0000006\n",
+			"Error reading data: Invalid dump on line 1: invalid value: \"108\", unsupported od format, expect the default, -b, -t x1 or -t x1z",
+		},
+		{
+			"-t d1",
+			"0000000  104  101  108  108  111   10\n0000006\n",
+			"Error reading data: Invalid dump on line 1: invalid value: \"\", unsupported od format, expect the default, -b, -t x1 or -t x1z",
+		},
+		{
+			"mixed widths",
+			"0000000 150 145 154 154 157 012 150 145\n0000010 6c 6c\n0000012\n",
+			"Error reading data: Invalid dump on line 2: unsupported od format, expect the default, -b, -t x1 or -t x1z",
+		},
+		{
+			"-A d rows",
+			"0000000 101 102 103 104 105 106 107 110 111 112 113 114 115 116 117 120\n" +
+				"0000016 121 122 123 124 125 126 127 130 131 132\n0000026\n",
+			"Error reading data: Invalid dump on line 2: offset E doesn't follow on from the previous row ending at 10",
+		},
+		{
+			"-A d end",
+			"0000000 101 102 103 104 105 106 107 110 111 112 113 114 115 116 117 120\n0000016\n",
+			"Error reading data: Invalid dump on line 2: end offset E doesn't match the data ending at 10",
+		},
+		{"-A n", "101 102 103\n", "Error reading data: Invalid dump on line 1: invalid offset: \"101\", expect od's default octal offsets or -A x"},
+		{"-A o -t x1 hex offset", "000001a 68\n", "Error reading data: Invalid dump on line 1: invalid offset: \"000001a\", expect od's default octal offsets or -A x"},
+	}
+	for _, c := range cases {
+		opts := options.Options{InputMode: options.Od, OutputMode: options.Raw, InputData: c.dump, Limit: math.MaxInt64}
+		reader, _, _, err := input.GetInput(&opts)
+		if err != nil {
+			t.Fatalf("Failed to create input reader, error: %v", err)
+		}
+		var writer strings.Builder
+		err = Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{})
+		if err == nil || err.Error() != c.expectedErr {
+			t.Errorf("%s: expected err: %q, got: %v", c.name, c.expectedErr, err)
+		}
+	}
+}
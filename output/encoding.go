@@ -0,0 +1,76 @@
+package output
+
+import (
+	"encoding/ascii85"
+	"encoding/base32"
+	"fmt"
+	"io"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+	"github.com/jcuga/hax/z85"
+)
+
+// outputEncoded writes data through a text encoder like base32 or ascii85.
+// Same as base64 output, opts.Display.Width wraps every width chars.
+func outputEncoded(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	var outWriter io.Writer
+	outWriter = writer
+	if opts.Display.Width > 0 { // wrap to add newlines every width bytes
+		outWriter, _ = NewFixedWidthWriter(outWriter, opts.Display.Width)
+	}
+
+	var encoder io.WriteCloser
+	suffix := ""
+	switch opts.OutputMode {
+	case options.Base32:
+		encoder = base32.NewEncoder(base32.StdEncoding, outWriter)
+	case options.Base32Hex:
+		encoder = base32.NewEncoder(base32.HexEncoding, outWriter)
+	case options.Ascii85:
+		encoder = ascii85.NewEncoder(outWriter)
+	case options.Ascii85Delimited:
+		outWriter.Write([]byte("<~"))
+		encoder = ascii85.NewEncoder(outWriter)
+		suffix = "~>"
+	case options.Z85:
+		encoder = z85.NewEncoder(outWriter)
+	default:
+		return fmt.Errorf("Unsupported encoded output mode: %v", opts.OutputMode)
+	}
+
+	buf := make([]byte, options.OutputBufferSize)
+	bytesWritten := int64(0) // num input bytes written, NOT the number of bytes the encoded output fills.
+	for {
+		var n int
+		var err error
+		// only read up to limit many bytes:
+		if opts.Limit-bytesWritten < options.OutputBufferSize {
+			n, err = reader.Read(buf[:opts.Limit-bytesWritten])
+		} else {
+			n, err = reader.Read(buf)
+		}
+
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+
+		if _, err := encoder.Write(buf[0:n]); err != nil {
+			return fmt.Errorf("Error encoding data: %v", err)
+		}
+
+		bytesWritten += int64(n)
+		if bytesWritten >= opts.Limit {
+			break
+		}
+	}
+	// needed to flush/encode any final, partial block of data
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	outWriter.Write([]byte(suffix))
+	return nil
+}
@@ -0,0 +1,93 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_outputEncoded(t *testing.T) {
+	type testCase struct {
+		mode     options.IOMode
+		width    int
+		data     string
+		expected string
+	}
+	cases := []testCase{
+		{options.Base32, 0, "hello world!", "NBSWY3DPEB3W64TMMQQQ===="},
+		{options.Base32Hex, 0, "hello world!", "D1IMOR3F41RMUSJCCGGG===="},
+		{options.Base32, 8, "hello world!", "NBSWY3DP\nEB3W64TM\nMQQQ===="},
+		{options.Ascii85, 0, "hello world!", "BOu!rD]j7BEbo80"},
+		{options.Ascii85, 0, "\x00\x00\x00\x00", "z"},
+		{options.Ascii85Delimited, 0, "hello world!", "<~BOu!rD]j7BEbo80~>"},
+		{options.Z85, 0, "\x86\x4F\xD2\x6F\xB5\x59\xF7\x5B", "HelloWorld"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		opts := options.Options{OutputMode: c.mode, Limit: math.MaxInt64, Display: options.DisplayOptions{Width: c.width}}
+		err := outputEncoded(&writer, input.NewFixedLengthBufferedReader(strings.NewReader(c.data)), options.IOInfo{}, opts)
+		if err != nil {
+			t.Errorf("mode: %v, unexpected err: %v", c.mode, err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("mode: %v, unexpected output, expected: %q, got: %q", c.mode, c.expected, result)
+		}
+	}
+
+	var writer strings.Builder
+	opts := options.Options{OutputMode: options.Z85, Limit: math.MaxInt64}
+	err := outputEncoded(&writer, input.NewFixedLengthBufferedReader(strings.NewReader("abc")), options.IOInfo{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "Z85 data must be a multiple of 4 bytes") {
+		t.Errorf("Expected z85 length error, got: %v", err)
+	}
+}
+
+func Test_Output_EncodedInput(t *testing.T) {
+	type testCase struct {
+		mode        options.IOMode
+		data        string
+		expected    string
+		expectedErr string
+	}
+	cases := []testCase{
+		{options.Base32, "NBSWY3DP\nEB3W64TM MQQQ====", "hello world!", ""},
+		{options.Base32Hex, "D1IMOR3F41RMUSJCCGGG====", "hello world!", ""},
+		{options.Ascii85, "BOu!rD]j7BEbo80", "hello world!", ""},
+		{options.Ascii85, "  <~BOu!rD]j7\nBEbo80~>ignored", "hello world!", ""},
+		{options.Ascii85Delimited, "<~BOu!rD]j7BEbo80~>", "hello world!", ""},
+		{options.Ascii85Delimited, "BOu!rD]j7BEbo80", "hello world!", ""},
+		{options.Ascii85, "<~z~>", "\x00\x00\x00\x00", ""},
+		{options.Z85, "Hello\nWorld", "\x86\x4F\xD2\x6F\xB5\x59\xF7\x5B", ""},
+		{options.Z85, "Hell~", "", "Invalid Z85 char"},
+		{options.Z85, "HelloWor", "", "Z85 text must be a multiple of 5 chars, 3 left over"},
+	}
+	for _, c := range cases {
+		opts := options.Options{InputMode: c.mode, OutputMode: options.Raw, InputData: c.data, Limit: math.MaxInt64}
+		reader, _, _, err := input.GetInput(&opts)
+		if err != nil {
+			t.Fatalf("Failed to create input reader, error: %v", err)
+		}
+		var writer strings.Builder
+		err = Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{})
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("data: %q, unexpected err: %v", c.data, err)
+		}
+		if c.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), c.expectedErr)) {
+			t.Errorf("data: %q, expected err containing: %q, got: %v", c.data, c.expectedErr, err)
+		}
+		if result := writer.String(); c.expectedErr == "" && result != c.expected {
+			t.Errorf("data: %q, unexpected result, expected: %q, got: %q", c.data, c.expected, result)
+		}
+	}
+
+	data := "\x00\x01\x80\xFFhello world, a bit longer.\x00\x00\x00\x00!!"
+	for _, mode := range []options.IOMode{options.Base32, options.Base32Hex, options.Ascii85, options.Ascii85Delimited, options.Z85} {
+		outOpts := options.Options{OutputMode: mode, Display: options.DisplayOptions{Width: 7}}
+		if result := outputThenInput(t, data, outOpts, options.IOInfo{StdoutIsPipe: true}, mode); result != data {
+			t.Errorf("mode: %v, unexpected result, expected: %q, got: %q", mode, data, result)
+		}
+	}
+}
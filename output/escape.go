@@ -0,0 +1,141 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+const upperHexDigits = "0123456789ABCDEF"
+
+func isHexDigitChar(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// outputCString writes bytes as the contents of a C/Python string literal.
+// Printable ascii is kept as-is, the rest are escapes like "\n" or "\xAB".
+// If opts.Display.Width is set, every width bytes becomes its own quoted
+// literal on a separate line, ex: "abc"\n"def", which both languages join.
+func outputCString(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	buf := make([]byte, options.OutputBufferSize)
+	bytesWritten := int64(0)
+	escapeBuf := bytes.Buffer{}
+	quoted := opts.Display.Width > 0
+	// a hex digit right after a "\xAB" escape would be part of it in C, so escape those too.
+	prevWasHexEscape := false
+
+	for {
+		var n int
+		var err error
+		// only read up to limit many bytes:
+		if opts.Limit-bytesWritten < options.OutputBufferSize {
+			n, err = reader.Read(buf[:opts.Limit-bytesWritten])
+		} else {
+			n, err = reader.Read(buf)
+		}
+
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+
+		escapeBuf.Reset()
+		for i, b := range buf[:n] {
+			count := bytesWritten + int64(i)
+			if quoted && count%int64(opts.Display.Width) == 0 {
+				if count > 0 {
+					escapeBuf.WriteString("\"\n")
+				}
+				escapeBuf.WriteByte('"')
+				prevWasHexEscape = false
+			}
+			hexEscape := false
+			switch {
+			case b == '\n':
+				escapeBuf.WriteString(`\n`)
+			case b == '\r':
+				escapeBuf.WriteString(`\r`)
+			case b == '\t':
+				escapeBuf.WriteString(`\t`)
+			case b == '\\':
+				escapeBuf.WriteString(`\\`)
+			case b == '"':
+				escapeBuf.WriteString(`\"`)
+			case b >= 0x20 && b <= 0x7E && !(prevWasHexEscape && isHexDigitChar(b)):
+				escapeBuf.WriteByte(b)
+			default:
+				escapeBuf.Write([]byte{'\\', 'x', upperHexDigits[b>>4], upperHexDigits[b&0x0F]})
+				hexEscape = true
+			}
+			prevWasHexEscape = hexEscape
+		}
+		writer.Write(escapeBuf.Bytes())
+
+		bytesWritten += int64(n)
+		if bytesWritten >= opts.Limit {
+			break
+		}
+	}
+	if quoted && bytesWritten > 0 {
+		writer.Write([]byte{'"'})
+	}
+	return nil
+}
+
+// outputUrlEncoded writes bytes with URL percent-encoding. Only unreserved
+// chars (RFC 3986) are kept as-is. With opts.PlusAsSpace, spaces are '+'.
+// opts.Display.Width wraps every width chars.
+func outputUrlEncoded(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	var outWriter io.Writer
+	outWriter = writer
+	if opts.Display.Width > 0 { // wrap to add newlines every width bytes
+		outWriter, _ = NewFixedWidthWriter(outWriter, opts.Display.Width)
+	}
+
+	buf := make([]byte, options.OutputBufferSize)
+	bytesWritten := int64(0)
+	encodedBuf := bytes.Buffer{}
+
+	for {
+		var n int
+		var err error
+		// only read up to limit many bytes:
+		if opts.Limit-bytesWritten < options.OutputBufferSize {
+			n, err = reader.Read(buf[:opts.Limit-bytesWritten])
+		} else {
+			n, err = reader.Read(buf)
+		}
+
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+
+		encodedBuf.Reset()
+		for _, b := range buf[:n] {
+			switch {
+			case (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9'),
+				b == '-', b == '.', b == '_', b == '~':
+				encodedBuf.WriteByte(b)
+			case b == ' ' && opts.PlusAsSpace:
+				encodedBuf.WriteByte('+')
+			default:
+				encodedBuf.Write([]byte{'%', upperHexDigits[b>>4], upperHexDigits[b&0x0F]})
+			}
+		}
+		outWriter.Write(encodedBuf.Bytes())
+
+		bytesWritten += int64(n)
+		if bytesWritten >= opts.Limit {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_outputEscaped(t *testing.T) {
+	type testCase struct {
+		opts     options.Options
+		expected string
+	}
+	data := "Hi\n\xAB\x01A \"q\" \\+"
+	cases := []testCase{
+		{options.Options{OutputMode: options.CString}, `Hi\n\xAB\x01\x41 \"q\" \\+`},
+		{options.Options{OutputMode: options.CString, Display: options.DisplayOptions{Width: 4}}, "\"Hi\\n\\xAB\"\n\"\\x01\\x41 \\\"\"\n\"q\\\" \\\\\"\n\"+\""},
+		{options.Options{OutputMode: options.CString, Limit: 2}, "Hi"},
+		{options.Options{OutputMode: options.UrlEncoded}, "Hi%0A%AB%01A%20%22q%22%20%5C%2B"},
+		{options.Options{OutputMode: options.UrlEncoded, PlusAsSpace: true}, "Hi%0A%AB%01A+%22q%22+%5C%2B"},
+		{options.Options{OutputMode: options.UrlEncoded, Display: options.DisplayOptions{Width: 8}}, "Hi%0A%AB\n%01A%20%\n22q%22%2\n0%5C%2B"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		if c.opts.Limit == 0 {
+			c.opts.Limit = math.MaxInt64
+		}
+		reader := input.NewFixedLengthBufferedReader(strings.NewReader(data))
+		var err error
+		if c.opts.OutputMode == options.CString {
+			err = outputCString(&writer, reader, options.IOInfo{}, c.opts)
+		} else {
+			err = outputUrlEncoded(&writer, reader, options.IOInfo{}, c.opts)
+		}
+		if err != nil {
+			t.Errorf("Unexpected err: %v", err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("opts: %+v, unexpected output.\nExpected:\n%s\n\ngot:\n%s", c.opts, c.expected, result)
+		}
+	}
+}
+
+func Test_Output_EscapedInput(t *testing.T) {
+	type testCase struct {
+		mode        options.IOMode
+		plusAsSpace bool
+		data        string
+		expected    string
+		expectedErr string
+	}
+	cases := []testCase{
+		{options.CString, false, `Hi\n\t\0\123\xAB\x7\\\'\"\a`, "Hi\n\t\x00S\xAB\x07\\'\"\a", ""},
+		{options.CString, false, `é\U0001F600`, "é😀", ""},
+		{options.CString, false, `\1234`, "S4", ""},
+		{options.CString, false, `\xABC`, "\xABC", ""},
+		{options.CString, false, "\"a\\\"b\"", "a\"b", ""},
+		{options.CString, false, "b\"abc\"\n  'd\"e' \"f\\\ng\"", "abcd\"efg", ""},
+		{options.CString, false, "bad \"quotes\"", "bad \"quotes\"", ""},
+		{options.CString, false, "b", "b", ""},
+		{options.CString, false, `ab\q`, "", "Invalid escape '\\q' at offset 2"},
+		{options.CString, false, `\x`, "", "Incomplete escape '\\x' at offset 0"},
+		{options.CString, false, `\u12`, "", "Incomplete escape '\\u12' at offset 0, needs 4 hex digits"},
+		{options.CString, false, `\uD800`, "", "Invalid unicode escape '\\uD800' at offset 0"},
+		{options.CString, false, `a\400`, "", "Octal escape '\\400' at offset 1 is larger than \\377"},
+		{options.CString, false, "\"abc", "", "Unterminated string literal, missing closing \""},
+		{options.CString, false, "\"abc\" x", "", "Unexpected 'x' between string literals at offset 6"},
+		{options.UrlEncoded, false, "a%20b+c%2f\n%C3%A9", "a b+c/é", ""},
+		{options.UrlEncoded, true, "a%20b+c", "a b c", ""},
+		{options.UrlEncoded, false, "ab%2", "", "Incomplete percent escape '%2' at offset 2"},
+		{options.UrlEncoded, false, "a%zz", "", "Invalid percent escape '%z' at offset 1"},
+	}
+	for _, c := range cases {
+		opts := options.Options{InputMode: c.mode, OutputMode: options.Raw, InputData: c.data, Limit: math.MaxInt64, PlusAsSpace: c.plusAsSpace}
+		reader, _, _, err := input.GetInput(&opts)
+		if err != nil {
+			t.Fatalf("Failed to create input reader, error: %v", err)
+		}
+		var writer strings.Builder
+		err = Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{})
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("data: %q, unexpected err: %v", c.data, err)
+		}
+		if c.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), c.expectedErr)) {
+			t.Errorf("data: %q, expected err containing: %q, got: %v", c.data, c.expectedErr, err)
+		}
+		if result := writer.String(); c.expectedErr == "" && result != c.expected {
+			t.Errorf("data: %q, unexpected result, expected: %q, got: %q", c.data, c.expected, result)
+		}
+	}
+
+	data := "\x00\x01\x80\xFFhello \"world\"\n\\+ \xABcd"
+	for _, width := range []int{0, 3} {
+		outOpts := options.Options{OutputMode: options.CString, Display: options.DisplayOptions{Width: width}}
+		if result := outputThenInput(t, data, outOpts, options.IOInfo{StdoutIsPipe: true}, options.CString); result != data {
+			t.Errorf("width: %d, unexpected result, expected: %q, got: %q", width, data, result)
+		}
+		outOpts = options.Options{OutputMode: options.UrlEncoded, Display: options.DisplayOptions{Width: width}}
+		if result := outputThenInput(t, data, outOpts, options.IOInfo{StdoutIsPipe: true}, options.UrlEncoded); result != data {
+			t.Errorf("width: %d, unexpected result, expected: %q, got: %q", width, data, result)
+		}
+	}
+}
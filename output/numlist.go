@@ -0,0 +1,82 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+// outputNumberList writes each byte as a decimal ("72, 101") or octal
+// ("0110 0145") number separated by opts.Separator. opts.Display.Width sets
+// numbers per line (0 is no wrapping), lines end with the separator minus any
+// trailing spaces so the output can be read back in.
+func outputNumberList(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	separator := opts.Separator
+	if separator == "" {
+		if opts.OutputMode == options.OctList {
+			separator = " "
+		} else {
+			separator = ", "
+		}
+	}
+	lineEnd := strings.TrimRight(separator, " ") + "\n"
+
+	buf := make([]byte, options.OutputBufferSize)
+	bytesWritten := int64(0)
+	numBuf := bytes.Buffer{}
+
+	for {
+		var n int
+		var err error
+		// only read up to limit many bytes:
+		if opts.Limit-bytesWritten < options.OutputBufferSize {
+			n, err = reader.Read(buf[:opts.Limit-bytesWritten])
+		} else {
+			n, err = reader.Read(buf)
+		}
+
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+
+		numBuf.Reset()
+		for i, b := range buf[:n] {
+			count := bytesWritten + int64(i)
+			if count > 0 {
+				if opts.Display.Width > 0 && count%int64(opts.Display.Width) == 0 {
+					numBuf.WriteString(lineEnd)
+				} else {
+					numBuf.WriteString(separator)
+				}
+			}
+			value := int64(b)
+			if opts.SignedBytes {
+				value = int64(int8(b))
+			}
+			if opts.OutputMode == options.OctList {
+				if value < 0 {
+					numBuf.WriteByte('-')
+					value = -value
+				}
+				numBuf.WriteString(fmt.Sprintf("0%03o", value))
+			} else {
+				numBuf.WriteString(strconv.FormatInt(value, 10))
+			}
+		}
+		writer.Write(numBuf.Bytes())
+
+		bytesWritten += int64(n)
+		if bytesWritten >= opts.Limit {
+			break
+		}
+	}
+	return nil
+}
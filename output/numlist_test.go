@@ -0,0 +1,95 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_outputNumberList(t *testing.T) {
+	type testCase struct {
+		opts     options.Options
+		expected string
+	}
+	cases := []testCase{
+		{options.Options{OutputMode: options.DecList}, "72, 105, 0, 255, 128"},
+		{options.Options{OutputMode: options.DecList, SignedBytes: true}, "72, 105, 0, -1, -128"},
+		{options.Options{OutputMode: options.DecList, Separator: " "}, "72 105 0 255 128"},
+		{options.Options{OutputMode: options.DecList, Display: options.DisplayOptions{Width: 2}}, "72, 105,\n0, 255,\n128"},
+		{options.Options{OutputMode: options.OctList}, "0110 0151 0000 0377 0200"},
+		{options.Options{OutputMode: options.OctList, SignedBytes: true}, "0110 0151 0000 -0001 -0200"},
+		{options.Options{OutputMode: options.OctList, Display: options.DisplayOptions{Width: 3}}, "0110 0151 0000\n0377 0200"},
+		{options.Options{OutputMode: options.OctList, Limit: 2}, "0110 0151"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		if c.opts.Limit == 0 {
+			c.opts.Limit = math.MaxInt64
+		}
+		err := outputNumberList(&writer, input.NewFixedLengthBufferedReader(strings.NewReader("Hi\x00\xFF\x80")), options.IOInfo{}, c.opts)
+		if err != nil {
+			t.Errorf("Unexpected err: %v", err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("opts: %+v, unexpected output.\nExpected:\n%q\n\ngot:\n%q", c.opts, c.expected, result)
+		}
+	}
+}
+
+func Test_Output_NumberListInput(t *testing.T) {
+	type testCase struct {
+		mode         options.IOMode
+		data         string
+		separator    string
+		noRangeCheck bool
+		expected     string
+		expectedErr  string
+	}
+	cases := []testCase{
+		{options.DecList, "72, 105, 0, 255, 128", "", false, "Hi\x00\xFF\x80", ""},
+		{options.DecList, "[72, 105, 0, -1, -128]", "", false, "Hi\x00\xFF\x80", ""},
+		{options.DecList, "{+72;105}\r\n(0)", "", false, "Hi\x00", ""},
+		{options.DecList, "72|105", "|", false, "Hi", ""},
+		{options.DecList, "72, 105, 256", "", false, "", "Value \"256\" out of range -128 to 255 (item 3, input offset 9)"},
+		{options.DecList, "72, -129", "", false, "", "Value \"-129\" out of range -128 to 255 (item 2, input offset 4)"},
+		{options.DecList, "1, 99999999999999999999", "", false, "", "Value \"99999999999999999999\" out of range -128 to 255 (item 2, input offset 3)"},
+		{options.DecList, "328, -1, 99999999999999999999", "", true, "H\xFF\xFF", ""},
+		{options.DecList, "72, 0x10", "", false, "", "Invalid decimal value \"0x10\" (item 2, input offset 4)"},
+		{options.DecList, "72, --1", "", false, "", "Invalid decimal value \"--1\" (item 2, input offset 4)"},
+		{options.DecList, "72|105", "", false, "", "Invalid decimal value \"72|105\" (item 1, input offset 0)"},
+		{options.OctList, "0110 0151 0 0377 -0200", "", false, "Hi\x00\xFF\x80", ""},
+		{options.OctList, "110,0o151", "", false, "Hi", ""},
+		{options.OctList, "0110 0400", "", false, "", "Value \"0400\" out of range -128 to 255 (item 2, input offset 5)"},
+		{options.OctList, "0110 018", "", false, "", "Invalid octal value \"018\" (item 2, input offset 5)"},
+	}
+	for _, c := range cases {
+		opts := options.Options{InputMode: c.mode, OutputMode: options.Raw, InputData: c.data, Limit: math.MaxInt64,
+			Separator: c.separator, NoRangeCheck: c.noRangeCheck}
+		reader, _, _, err := input.GetInput(&opts)
+		if err != nil {
+			t.Fatalf("Failed to create input reader, error: %v", err)
+		}
+		var writer strings.Builder
+		err = Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{})
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("data: %q, unexpected err: %v", c.data, err)
+		}
+		if c.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), c.expectedErr)) {
+			t.Errorf("data: %q, expected err containing: %q, got: %v", c.data, c.expectedErr, err)
+		}
+		if result := writer.String(); c.expectedErr == "" && result != c.expected {
+			t.Errorf("data: %q, unexpected result, expected: %q, got: %q", c.data, c.expected, result)
+		}
+	}
+
+	data := "\x00\x01\x80\xFFhello"
+	for _, mode := range []options.IOMode{options.DecList, options.OctList} {
+		outOpts := options.Options{OutputMode: mode, SignedBytes: true, Display: options.DisplayOptions{Width: 3}}
+		if result := outputThenInput(t, data, outOpts, options.IOInfo{StdoutIsPipe: true}, mode); result != data {
+			t.Errorf("mode: %v, unexpected result, expected: %q, got: %q", mode, data, result)
+		}
+	}
+}
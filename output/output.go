@@ -2,8 +2,10 @@ package output
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"math"
 
 	"github.com/jcuga/hax/commands"
 	"github.com/jcuga/hax/input"
@@ -18,8 +20,8 @@ func Output(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo op
 	w := bufio.NewWriter(writer)
 
 	defer func() {
-		if !ioInfo.StdoutIsPipe {
-			// add newline to end of terminal output
+		if !ioInfo.StdoutIsPipe && !isCompressedOutput(opts.OutputMode) {
+			// add newline to end of terminal output, unless that would corrupt binary output
 			fmt.Fprintf(w, "\n")
 		}
 		// always flush output writer!
@@ -38,15 +40,26 @@ func Output(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo op
 			return commands.StringsUtf8(w, reader, ioInfo, opts, cmdArgs)
 		case options.Search:
 			return commands.Search(w, reader, ioInfo, opts, cmdArgs)
+		case options.Replace:
+			// replaced data is then output like any other input below
+			replaced, err := commands.NewReplaceReader(reader, opts, cmdArgs)
+			if err != nil {
+				return err
+			}
+			reader = input.NewFixedLengthBufferedReader(replaced)
+			opts.Limit = math.MaxInt64 // already applied to the data being replaced
 		default:
 			return fmt.Errorf("Unhandled command: %q", options.CommandToString(cmd))
 		}
 	}
 
 	switch opts.OutputMode {
-	case options.Base64:
+	case options.Base64, options.Base64Url, options.Base64Raw, options.Base64UrlRaw, options.Base64Mime:
 		return outputBase64(w, reader, ioInfo, opts)
 	case options.Display:
+		if len(opts.Ranges) > 1 && cmd == options.NoCommand {
+			return displayRanges(w, reader, ioInfo, opts)
+		}
 		return displayHex(w, reader, ioInfo, opts)
 	case options.Hex:
 		return outputHex(w, reader, ioInfo, opts)
@@ -58,7 +71,53 @@ func Output(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo op
 		return outputHexAscii(w, reader, ioInfo, opts)
 	case options.Raw:
 		return outputRaw(w, reader, ioInfo, opts)
+	case options.Xxd, options.HexdumpCanonical, options.Od:
+		return outputDump(w, reader, ioInfo, opts)
+	case options.Binary:
+		return outputBinary(w, reader, ioInfo, opts)
+	case options.Base32, options.Base32Hex, options.Ascii85, options.Ascii85Delimited, options.Z85:
+		return outputEncoded(w, reader, ioInfo, opts)
+	case options.DecList, options.OctList:
+		return outputNumberList(w, reader, ioInfo, opts)
+	case options.CSource, options.GoSource, options.PythonSource, options.RustSource, options.JavaSource:
+		return outputSource(w, reader, ioInfo, opts)
+	case options.CString:
+		return outputCString(w, reader, ioInfo, opts)
+	case options.IntelHex:
+		return outputIntelHex(w, reader, ioInfo, opts)
+	case options.SRecord:
+		return outputSRecord(w, reader, ioInfo, opts)
+	case options.UrlEncoded:
+		return outputUrlEncoded(w, reader, ioInfo, opts)
+	case options.GzipCompressed, options.ZlibCompressed, options.DeflateCompressed:
+		return outputCompressed(w, reader, ioInfo, opts)
 	default:
 		return fmt.Errorf("Unsupported or not implemented output mode: %v", opts.OutputMode)
 	}
 }
+
+// readAll reads up to opts.Limit bytes for output modes that need all of the
+// data up front, ex: to write its size before the data.
+func readAll(reader *input.FixedLengthBufferedReader, opts options.Options) ([]byte, error) {
+	buf := make([]byte, options.OutputBufferSize)
+	data := bytes.Buffer{}
+	for int64(data.Len()) < opts.Limit {
+		var n int
+		var err error
+		// only read up to limit many bytes:
+		if opts.Limit-int64(data.Len()) < options.OutputBufferSize {
+			n, err = reader.Read(buf[:opts.Limit-int64(data.Len())])
+		} else {
+			n, err = reader.Read(buf)
+		}
+
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("Error reading data: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+		data.Write(buf[:n])
+	}
+	return data.Bytes(), nil
+}
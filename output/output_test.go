@@ -30,7 +30,7 @@ func Test_Output_HexListOutputThenInputToRaw(t *testing.T) {
 	// NOTE: use GetInput to get wrapped reader with proper "mode" based on opts.InputMode.
 	// One can't simply set the input mode on opts and pass it to Output().
 	// Output does not modify/wrap the input reader, the GetInput func does.
-	reader, closer, isStdin, err := input.GetInput(opts1)
+	reader, closer, isStdin, err := input.GetInput(&opts1)
 	ioInfo := options.IOInfo{InputIsStdin: isStdin}
 	if err != nil {
 		t.Fatalf("Failed to create input reader, error: %v", err)
@@ -54,7 +54,7 @@ func Test_Output_HexListOutputThenInputToRaw(t *testing.T) {
 		Limit:      math.MaxInt64,
 		Display:    options.DisplayOptions{Width: 8},
 	}
-	reader, closer, isStdin, err = input.GetInput(opts2)
+	reader, closer, isStdin, err = input.GetInput(&opts2)
 	ioInfo = options.IOInfo{StdoutIsPipe: true, InputIsStdin: isStdin}
 	if err != nil {
 		t.Fatalf("Failed to create input reader, error: %v", err)
@@ -75,3 +75,89 @@ func Test_Output_HexListOutputThenInputToRaw(t *testing.T) {
 		t.Fatalf("Unexpected raw output.\nExpected:\n%q\n\ngot:\n%q", original, result)
 	}
 }
+
+// outputThenInput outputs data per outOpts and then parses that output back in
+// with inMode, returning the raw bytes that come out the other end.
+func outputThenInput(t *testing.T, data string, outOpts options.Options, ioInfo options.IOInfo, inMode options.IOMode) string {
+	t.Helper()
+	var writer strings.Builder
+	outOpts.InputData = data
+	outOpts.InputMode = options.Raw
+	if outOpts.Limit == 0 {
+		outOpts.Limit = math.MaxInt64
+	}
+	reader, _, _, err := input.GetInput(&outOpts)
+	if err != nil {
+		t.Fatalf("Failed to create input reader, error: %v", err)
+	}
+	if err := Output(&writer, reader, ioInfo, outOpts, options.NoCommand, []string{}); err != nil {
+		t.Fatalf("Unexpected output error: %v", err)
+	}
+
+	inOpts := options.Options{
+		InputMode:  inMode,
+		OutputMode: options.Raw,
+		InputData:  writer.String(),
+		Limit:      math.MaxInt64,
+	}
+	reader, _, _, err = input.GetInput(&inOpts)
+	if err != nil {
+		t.Fatalf("Failed to create input reader, error: %v", err)
+	}
+	writer.Reset()
+	if err := Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, inOpts, options.NoCommand, []string{}); err != nil {
+		t.Fatalf("Unexpected error parsing output back in: %v", err)
+	}
+	return writer.String()
+}
+
+func Test_Output_DisplayOutputThenInput(t *testing.T) {
+	data := "\x01\x00\x00I'm a little tea pot,\x00\x00 short and stout.\t\r\n\xFF" + strings.Repeat("\x00", 100) + "the end."
+	type testCase struct {
+		name     string
+		display  options.DisplayOptions
+		offset   int64
+		pretty   bool
+		expected string
+	}
+	cases := []testCase{
+		{"default", options.DisplayOptions{Width: 16, PageSize: 4}, 0, false, data},
+		{"narrow", options.DisplayOptions{Width: 5, PageSize: 4}, 0, false, data},
+		{"sub-width", options.DisplayOptions{Width: 20, SubWidth: 4, PageSize: 2}, 0, false, data},
+		{"offset", options.DisplayOptions{Width: 16, SubWidth: 3, PageSize: 4}, 7, false, data[7:]},
+		{"quiet", options.DisplayOptions{Width: 16, Quiet: true}, 0, false, data},
+		{"pretty", options.DisplayOptions{Width: 16, SubWidth: 8, PageSize: 4}, 0, true, data},
+		{"omit-zeros", options.DisplayOptions{Width: 8, PageSize: 2, OmitZeroPages: true}, 0, false, data},
+		// leading zeros of the first row look just like offset padding:
+		{"hide-zeros", options.DisplayOptions{Width: 16, SubWidth: 4, PageSize: 4, HideZerosBytes: true}, 0, false, data},
+		{"hide-zeros offset", options.DisplayOptions{Width: 8, HideZerosBytes: true, OmitZeroPages: true, PageSize: 2}, 1, false, data[3:]},
+	}
+	for _, c := range cases {
+		outOpts := options.Options{OutputMode: options.Display, Offset: c.offset, Display: c.display}
+		result := outputThenInput(t, data, outOpts,
+			options.IOInfo{StdoutIsPipe: !c.pretty, OutputPretty: c.pretty}, options.Display)
+		if result != c.expected {
+			t.Errorf("%s: unexpected result.\nExpected:\n%q\n\ngot:\n%q", c.name, c.expected, result)
+		}
+	}
+}
+
+func Test_Output_DisplayInputErrors(t *testing.T) {
+	cases := map[string]string{
+		"            0: 01 02 0x":                                           "Invalid hex byte on line 1: \"0x\"",
+		"           10: 01 02 03\n\n            0: 01":                      "Display rows out of order or missing between offsets 10 and 0, line 3",
+		"\n                0  1\n            0: 01 02\n\n            4: 05": "Display rows out of order or missing between offsets 0 and 4, line 5",
+	}
+	for data, expectedErr := range cases {
+		opts := options.Options{InputMode: options.Display, OutputMode: options.Raw, InputData: data, Limit: math.MaxInt64}
+		reader, _, _, err := input.GetInput(&opts)
+		if err != nil {
+			t.Fatalf("Failed to create input reader, error: %v", err)
+		}
+		var writer strings.Builder
+		err = Output(&writer, reader, options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{})
+		if err == nil || !strings.Contains(err.Error(), expectedErr) {
+			t.Errorf("input: %q, expected err containing: %q, got: %v", data, expectedErr, err)
+		}
+	}
+}
@@ -3,10 +3,10 @@ package output
 import (
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/jcuga/hax/input"
 	"github.com/jcuga/hax/options"
+	"github.com/jcuga/hax/util"
 )
 
 func outputRaw(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
@@ -33,7 +33,7 @@ func outputRaw(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo
 		// For TTY/terminal (ie not a pipe) output, warn and ask if first batch looks like non-printables
 		if !ioInfo.StdoutIsPipe && bytesWritten == 0 && containsNonPrintable(buf[:n]) {
 			if !opts.Yes {
-				if !promptForYes("Output may be a binary file.  See it anyway?") { // TODO: better wording--see curl for example? IIRC does similar.
+				if !util.PromptForYes("Output may be a binary file.  See it anyway?") { // TODO: better wording--see curl for example? IIRC does similar.
 					return nil
 				}
 			}
@@ -59,14 +59,3 @@ func containsNonPrintable(data []byte) bool {
 	}
 	return false
 }
-
-// TODO: move to common/util module if needed elsewhere
-func promptForYes(msg string) bool {
-	fmt.Fprintf(os.Stderr, "%s (y/n): ", msg)
-	var answer string
-	fmt.Scanln(&answer)
-	if len(answer) > 0 && (answer[0] == 'y' || answer[0] == 'Y') {
-		return true
-	}
-	return false
-}
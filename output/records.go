@@ -0,0 +1,146 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+const defaultRecordLength = 16
+
+// recordLayout gets the address of the first byte (opts.BaseAddress or 0)
+// and bytes per record (opts.Display.Width or 16), checking the record length
+// is at most maxLength and all of the data fits in 32 bit addresses.
+func recordLayout(opts options.Options, dataLen int, maxLength int) (int64, int, error) {
+	base := opts.BaseAddress
+	if base < 0 {
+		base = 0
+	}
+	recordLen := defaultRecordLength
+	if opts.Display.Width > 0 {
+		recordLen = opts.Display.Width
+	}
+	if recordLen > maxLength {
+		return 0, 0, fmt.Errorf("Record length (--width) of %d is too large, max is %d", recordLen, maxLength)
+	}
+	if base+int64(dataLen) > 0x100000000 {
+		return 0, 0, fmt.Errorf("Data of %d bytes at base address 0x%X goes past 32 bit addresses", dataLen, base)
+	}
+	return base, recordLen, nil
+}
+
+// writeIntelHexRecord writes ":LLAAAATT<data>CC" where the checksum CC is the
+// two's complement of the sum of the other bytes.
+func writeIntelHexRecord(writer io.Writer, recordType byte, address uint16, data []byte) {
+	sum := byte(len(data)) + byte(address>>8) + byte(address) + recordType
+	fmt.Fprintf(writer, ":%02X%04X%02X", len(data), address, recordType)
+	for _, b := range data {
+		fmt.Fprintf(writer, "%02X", b)
+		sum += b
+	}
+	fmt.Fprintf(writer, "%02X", -sum)
+}
+
+// outputIntelHex writes data records of opts.Display.Width bytes starting at
+// opts.BaseAddress, adding extended linear address records past 64K.
+func outputIntelHex(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	data, err := readAll(reader, opts)
+	if err != nil {
+		return err
+	}
+	base, recordLen, err := recordLayout(opts, len(data), 0xFF)
+	if err != nil {
+		return err
+	}
+
+	upperAddress := int64(0)
+	for i := 0; i < len(data); {
+		address := base + int64(i)
+		if address>>16 != upperAddress {
+			upperAddress = address >> 16
+			writeIntelHexRecord(writer, 0x04, 0, []byte{byte(upperAddress >> 8), byte(upperAddress)})
+			fmt.Fprintf(writer, "\n")
+		}
+		// records can't cross a 64K boundary
+		n := recordLen
+		if len(data)-i < n {
+			n = len(data) - i
+		}
+		if toBoundary := 0x10000 - int(address&0xFFFF); toBoundary < n {
+			n = toBoundary
+		}
+		writeIntelHexRecord(writer, 0x00, uint16(address), data[i:i+n])
+		fmt.Fprintf(writer, "\n")
+		i += n
+	}
+	writeIntelHexRecord(writer, 0x01, 0, nil)
+	return nil
+}
+
+// writeSRecord writes "S<type><count><address><data><checksum>" where the
+// checksum is the ones' complement of the sum of the count, address and data.
+func writeSRecord(writer io.Writer, recordType byte, addressLen int, address int64, data []byte) {
+	count := byte(addressLen + len(data) + 1)
+	sum := count
+	fmt.Fprintf(writer, "S%c%02X", recordType, count)
+	for i := addressLen - 1; i >= 0; i-- {
+		b := byte(address >> uint(8*i))
+		fmt.Fprintf(writer, "%02X", b)
+		sum += b
+	}
+	for _, b := range data {
+		fmt.Fprintf(writer, "%02X", b)
+		sum += b
+	}
+	fmt.Fprintf(writer, "%02X", ^sum)
+}
+
+// outputSRecord writes S1, S2 or S3 data records (whichever is the smallest
+// that fits the last address) of opts.Display.Width bytes starting at
+// opts.BaseAddress, along with a header, record count and end record.
+func outputSRecord(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	data, err := readAll(reader, opts)
+	if err != nil {
+		return err
+	}
+
+	lastAddress := opts.BaseAddress + int64(len(data)) - 1
+	if opts.BaseAddress < 0 {
+		lastAddress = int64(len(data)) - 1
+	}
+	dataType, endType, addressLen := byte('1'), byte('9'), 2
+	if lastAddress > 0xFFFFFF {
+		dataType, endType, addressLen = '3', '7', 4
+	} else if lastAddress > 0xFFFF {
+		dataType, endType, addressLen = '2', '8', 3
+	}
+	// count byte covers address, data and checksum
+	base, recordLen, err := recordLayout(opts, len(data), 0xFF-addressLen-1)
+	if err != nil {
+		return err
+	}
+
+	writeSRecord(writer, '0', 2, 0, nil)
+	fmt.Fprintf(writer, "\n")
+	numRecords := 0
+	for i := 0; i < len(data); i += recordLen {
+		end := i + recordLen
+		if end > len(data) {
+			end = len(data)
+		}
+		writeSRecord(writer, dataType, addressLen, base+int64(i), data[i:end])
+		fmt.Fprintf(writer, "\n")
+		numRecords++
+	}
+	if numRecords <= 0xFFFF {
+		writeSRecord(writer, '5', 2, int64(numRecords), nil)
+		fmt.Fprintf(writer, "\n")
+	} else if numRecords <= 0xFFFFFF {
+		writeSRecord(writer, '6', 3, int64(numRecords), nil)
+		fmt.Fprintf(writer, "\n")
+	}
+	writeSRecord(writer, endType, addressLen, 0, nil)
+	return nil
+}
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_outputRecords(t *testing.T) {
+	type testCase struct {
+		opts     options.Options
+		data     string
+		expected string
+	}
+	cases := []testCase{
+		{options.Options{OutputMode: options.IntelHex, BaseAddress: -1}, "Hello",
+			":0500000048656C6C6F07\n:00000001FF"},
+		{options.Options{OutputMode: options.IntelHex, BaseAddress: 0x1FFFE, Display: options.DisplayOptions{Width: 4}}, "Hello",
+			":020000040001F9\n:02FFFE00486554\n:020000040002F8\n:030000006C6C6FB6\n:00000001FF"},
+		{options.Options{OutputMode: options.IntelHex, BaseAddress: -1}, "", ":00000001FF"},
+		{options.Options{OutputMode: options.SRecord, BaseAddress: -1}, "Hello",
+			"S0030000FC\nS108000048656C6C6F03\nS5030001FB\nS9030000FC"},
+		{options.Options{OutputMode: options.SRecord, BaseAddress: 0xFFFE, Display: options.DisplayOptions{Width: 4}}, "Hello",
+			"S0030000FC\nS20800FFFE48656C6C75\nS2050100026F88\nS5030002FA\nS804000000FB"},
+		{options.Options{OutputMode: options.SRecord, BaseAddress: 0x08000000}, "Hi",
+			"S0030000FC\nS3070800000048693F\nS5030001FB\nS70500000000FA"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		c.opts.Limit = math.MaxInt64
+		reader := input.NewFixedLengthBufferedReader(strings.NewReader(c.data))
+		var err error
+		if c.opts.OutputMode == options.IntelHex {
+			err = outputIntelHex(&writer, reader, options.IOInfo{}, c.opts)
+		} else {
+			err = outputSRecord(&writer, reader, options.IOInfo{}, c.opts)
+		}
+		if err != nil {
+			t.Errorf("Unexpected err: %v", err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("opts: %+v, unexpected output.\nExpected:\n%s\n\ngot:\n%s", c.opts, c.expected, result)
+		}
+	}
+
+	opts := options.Options{OutputMode: options.SRecord, BaseAddress: -1, Limit: math.MaxInt64, Display: options.DisplayOptions{Width: 253}}
+	err := outputSRecord(&strings.Builder{}, input.NewFixedLengthBufferedReader(strings.NewReader("Hi")), options.IOInfo{}, opts)
+	if err == nil || err.Error() != "Record length (--width) of 253 is too large, max is 252" {
+		t.Errorf("Expected record length error, got: %v", err)
+	}
+	opts = options.Options{OutputMode: options.IntelHex, BaseAddress: 0xFFFFFFFF, Limit: math.MaxInt64}
+	err = outputIntelHex(&strings.Builder{}, input.NewFixedLengthBufferedReader(strings.NewReader("Hi")), options.IOInfo{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "goes past 32 bit addresses") {
+		t.Errorf("Expected address range error, got: %v", err)
+	}
+}
+
+func Test_Output_RecordsInput(t *testing.T) {
+	type testCase struct {
+		mode        options.IOMode
+		data        string
+		opts        options.Options
+		expected    string
+		expectedErr string
+		segments    string
+	}
+	ihex := ":020000040800F2\n:0400000001020304F2\r\n\n:02000800AABB91\n:00000001FF\n:01000000FF00"
+	srec := "S00600004844521B\nS3090800000001020304E4\nS3070800000AAABB81\nS5030002FA\nS70508000000F2\n"
+	cases := []testCase{
+		{options.IntelHex, ihex, options.Options{FillByte: 0xFF, BaseAddress: -1}, "\x01\x02\x03\x04\xFF\xFF\xFF\xFF\xAA\xBB", "", ""},
+		{options.IntelHex, ihex, options.Options{FillByte: 0x00, BaseAddress: 0x07FFFFFE}, "\x00\x00\x01\x02\x03\x04\x00\x00\x00\x00\xAA\xBB", "", ""},
+		{options.IntelHex, ihex, options.Options{Sparse: true, BaseAddress: -1}, "\x01\x02\x03\x04\xAA\xBB", "",
+			"Segment 0x08000000-0x08000003 (4 bytes)\nSegment 0x08000008-0x08000009 (2 bytes)\n"},
+		{options.IntelHex, ":020000021200EA\n:0100000042BD", options.Options{BaseAddress: 0x12000}, "B", "", ""},
+		{options.SRecord, srec, options.Options{FillByte: 0xFF, BaseAddress: -1}, "\x01\x02\x03\x04\xFF\xFF\xFF\xFF\xFF\xFF\xAA\xBB", "", ""},
+		{options.SRecord, "S1050010AABB85\nS9030000FC", options.Options{BaseAddress: -1}, "\xAA\xBB", "", ""},
+		{options.IntelHex, ihex, options.Options{BaseAddress: 0x08000001}, "", "Record on line 2 at address 0x8000000 is before --base-address 0x8000001", ""},
+		{options.IntelHex, ":0400000001020304F3", options.Options{BaseAddress: -1}, "",
+			"Invalid record on line 1: Intel HEX checksum mismatch, expected 0xF2, got 0xF3", ""},
+		{options.IntelHex, ":0500000001020304F2", options.Options{BaseAddress: -1}, "",
+			"Invalid record on line 1: Intel HEX record length 0x05 does not match 4 data bytes", ""},
+		{options.IntelHex, "0400000001020304F2", options.Options{BaseAddress: -1}, "",
+			"Invalid record on line 1: Intel HEX record must start with ':'", ""},
+		// out of order and overlapping records:
+		{options.IntelHex, ":0400040001020304EE\n:0100000042BD", options.Options{BaseAddress: -1}, "B\x00\x00\x00\x01\x02\x03\x04", "", ""},
+		{options.IntelHex, ":0400040001020304EE\n:020007000405EE\n:0100000042BD\n:020006000304F1", options.Options{FillByte: 0xFF, BaseAddress: -1},
+			"B\xFF\xFF\xFF\x01\x02\x03\x04\x05", "", ""},
+		{options.IntelHex, ":0100100042AD\n:0100000041BE", options.Options{Sparse: true, BaseAddress: -1}, "AB", "",
+			"Segment 0x00000000-0x00000000 (1 bytes)\nSegment 0x00000010-0x00000010 (1 bytes)\n"},
+		{options.SRecord, "S1050010AABB85\nS1050000CCDD51\nS9030000FC", options.Options{BaseAddress: -1},
+			"\xCC\xDD" + strings.Repeat("\x00", 14) + "\xAA\xBB", "", ""},
+		{options.IntelHex, ":0400040001020304EE\n:020006000303F2", options.Options{BaseAddress: -1}, "",
+			"Record on line 2 overlaps other records with different data at address 0x7", ""},
+		{options.SRecord, "S1050010AABB8C", options.Options{BaseAddress: -1}, "",
+			"Invalid record on line 1: S-record checksum mismatch, expected 0x85, got 0x8C", ""},
+		{options.SRecord, "S4050010AABB85", options.Options{BaseAddress: -1}, "",
+			"Invalid record on line 1: Unknown S-record type S4", ""},
+	}
+	for _, c := range cases {
+		opts := c.opts
+		opts.InputMode = c.mode
+		opts.OutputMode = options.Raw
+		opts.InputData = c.data
+		opts.Limit = math.MaxInt64
+		var segments strings.Builder
+		var modeReader *input.RecordReader
+		if c.mode == options.IntelHex {
+			modeReader = input.NewIntelHexReader(strings.NewReader(c.data), opts.FillByte, opts.Sparse, &segments, opts.BaseAddress)
+		} else {
+			modeReader = input.NewSRecordReader(strings.NewReader(c.data), opts.FillByte, opts.Sparse, &segments, opts.BaseAddress)
+		}
+		var writer strings.Builder
+		err := Output(&writer, input.NewFixedLengthBufferedReader(modeReader), options.IOInfo{StdoutIsPipe: true}, opts, options.NoCommand, []string{})
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("data: %q, unexpected err: %v", c.data, err)
+		}
+		if c.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), c.expectedErr)) {
+			t.Errorf("data: %q, expected err containing: %q, got: %v", c.data, c.expectedErr, err)
+		}
+		if result := writer.String(); c.expectedErr == "" && result != c.expected {
+			t.Errorf("data: %q, unexpected result, expected: %q, got: %q", c.data, c.expected, result)
+		}
+		if result := segments.String(); result != c.segments {
+			t.Errorf("data: %q, unexpected segments, expected: %q, got: %q", c.data, c.segments, result)
+		}
+	}
+
+	data := strings.Repeat("\x00\x01\x80\xFFhello", 30)
+	for _, mode := range []options.IOMode{options.IntelHex, options.SRecord} {
+		outOpts := options.Options{OutputMode: mode, BaseAddress: 0xFFF0, Limit: math.MaxInt64, Display: options.DisplayOptions{Width: 7}}
+		var writer strings.Builder
+		err := Output(&writer, input.NewFixedLengthBufferedReader(strings.NewReader(data)), options.IOInfo{StdoutIsPipe: true}, outOpts, options.NoCommand, []string{})
+		if err != nil {
+			t.Fatalf("Unexpected output error: %v", err)
+		}
+		// starts at the first record's address
+		inOpts := options.Options{InputMode: mode, OutputMode: options.Raw, InputData: writer.String(), Limit: math.MaxInt64, BaseAddress: -1}
+		reader, _, _, err := input.GetInput(&inOpts)
+		if err != nil {
+			t.Fatalf("Failed to create input reader, error: %v", err)
+		}
+		var result strings.Builder
+		if err := Output(&result, reader, options.IOInfo{StdoutIsPipe: true}, inOpts, options.NoCommand, []string{}); err != nil {
+			t.Errorf("mode: %v, unexpected input error: %v", mode, err)
+		}
+		if result.String() != data {
+			t.Errorf("mode: %v, unexpected result, expected: %q, got: %q", mode, data, result.String())
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+// sourceFormat is how a language declares a variable holding some bytes.
+// Each line of bytes is linePrefix, items joined by itemSep, then lineSuffix
+// (or lastLineSuffix for the final line).
+type sourceFormat struct {
+	width          int // bytes per line unless opts.Display.Width set
+	linePrefix     string
+	itemSep        string
+	lineSuffix     string
+	lastLineSuffix string
+	// written instead of any lines when there are no bytes
+	empty      string
+	formatByte func(b byte) string
+	writeStart func(writer io.Writer, name string, size int)
+	writeEnd   func(writer io.Writer, name string, size int)
+	// default names are upper case, ex: rust's statics
+	upperName bool
+}
+
+func hexByteLiteral(b byte) string {
+	return fmt.Sprintf("0x%02x", b)
+}
+
+var cSourceFormat = sourceFormat{
+	width:          12,
+	linePrefix:     "  ",
+	itemSep:        ", ",
+	lineSuffix:     ",\n",
+	lastLineSuffix: "\n",
+	// an empty initializer list isn't valid C, so use a single zero byte
+	// and let the _len of 0 say there's no data.
+	empty:      "0",
+	formatByte: hexByteLiteral,
+	writeStart: func(writer io.Writer, name string, size int) {
+		if size == 0 {
+			fmt.Fprintf(writer, "#include <stddef.h>\n\nunsigned char %s[1] = {", name)
+			return
+		}
+		fmt.Fprintf(writer, "#include <stddef.h>\n\nunsigned char %s[] = {\n", name)
+	},
+	writeEnd: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, "};\nsize_t %s_len = %d;", name, size)
+	},
+}
+
+var goSourceFormat = sourceFormat{
+	width:          12,
+	linePrefix:     "\t",
+	itemSep:        ", ",
+	lineSuffix:     ",\n",
+	lastLineSuffix: ",\n",
+	formatByte:     hexByteLiteral,
+	writeStart: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, "var %s = []byte{\n", name)
+	},
+	writeEnd: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, "}")
+	},
+}
+
+var pythonSourceFormat = sourceFormat{
+	width:          16,
+	linePrefix:     "    b\"",
+	lineSuffix:     "\"\n",
+	lastLineSuffix: "\"\n",
+	empty:          "    b\"\"\n",
+	formatByte: func(b byte) string {
+		if b >= 0x20 && b <= 0x7E && b != '\\' && b != '"' {
+			return string(rune(b))
+		}
+		return fmt.Sprintf("\\x%02x", b)
+	},
+	writeStart: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, "%s = (\n", name)
+	},
+	writeEnd: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, ")")
+	},
+}
+
+var rustSourceFormat = sourceFormat{
+	width:          12,
+	linePrefix:     "    ",
+	itemSep:        ", ",
+	lineSuffix:     ",\n",
+	lastLineSuffix: ",\n",
+	formatByte:     hexByteLiteral,
+	writeStart: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, "pub static %s: [u8; %d] = [\n", name, size)
+	},
+	writeEnd: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, "];")
+	},
+	upperName: true,
+}
+
+var javaSourceFormat = sourceFormat{
+	width:          12,
+	linePrefix:     "    ",
+	itemSep:        ", ",
+	lineSuffix:     ",\n",
+	lastLineSuffix: "\n",
+	// java bytes are signed, 0x80 and up need a cast as hex literals
+	formatByte: func(b byte) string {
+		return fmt.Sprintf("%d", int8(b))
+	},
+	writeStart: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, "byte[] %s = new byte[]{\n", name)
+	},
+	writeEnd: func(writer io.Writer, name string, size int) {
+		fmt.Fprintf(writer, "};")
+	},
+}
+
+// sourceVarName makes a valid identifier out of name, ex: "fixtures/a-1.bin"
+// becomes "a_1_bin" like xxd -i does. Defaults to "data" when empty.
+func sourceVarName(name string) string {
+	if name == "" {
+		return "data"
+	}
+	var sb strings.Builder
+	for i, c := range filepath.Base(name) {
+		if c >= '0' && c <= '9' && i == 0 {
+			sb.WriteByte('_')
+		}
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// outputSource writes a complete variable declaration for the bytes in one
+// of several languages. Since some need the size up front (ex: rust's
+// [u8; N]), all of the data is read before writing anything.
+func outputSource(writer io.Writer, reader *input.FixedLengthBufferedReader, ioInfo options.IOInfo, opts options.Options) error {
+	var format sourceFormat
+	switch opts.OutputMode {
+	case options.CSource:
+		format = cSourceFormat
+	case options.GoSource:
+		format = goSourceFormat
+	case options.PythonSource:
+		format = pythonSourceFormat
+	case options.RustSource:
+		format = rustSourceFormat
+	case options.JavaSource:
+		format = javaSourceFormat
+	default:
+		return fmt.Errorf("Unsupported source output mode: %v", opts.OutputMode)
+	}
+
+	name := sourceVarName(opts.VarName)
+	if opts.VarName == "" {
+		name = sourceVarName(opts.Filename)
+		if format.upperName {
+			name = strings.ToUpper(name)
+		}
+	}
+	width := format.width
+	if opts.Display.Width > 0 {
+		width = opts.Display.Width
+	}
+
+	dataBytes, err := readAll(reader, opts)
+	if err != nil {
+		return err
+	}
+	format.writeStart(writer, name, len(dataBytes))
+	if len(dataBytes) == 0 {
+		io.WriteString(writer, format.empty)
+	}
+	for lineStart := 0; lineStart < len(dataBytes); lineStart += width {
+		lineEnd := lineStart + width
+		if lineEnd > len(dataBytes) {
+			lineEnd = len(dataBytes)
+		}
+		io.WriteString(writer, format.linePrefix)
+		for i, b := range dataBytes[lineStart:lineEnd] {
+			if i > 0 {
+				io.WriteString(writer, format.itemSep)
+			}
+			io.WriteString(writer, format.formatByte(b))
+		}
+		if lineEnd == len(dataBytes) {
+			io.WriteString(writer, format.lastLineSuffix)
+		} else {
+			io.WriteString(writer, format.lineSuffix)
+		}
+	}
+	format.writeEnd(writer, name, len(dataBytes))
+	return nil
+}
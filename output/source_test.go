@@ -0,0 +1,64 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jcuga/hax/input"
+	"github.com/jcuga/hax/options"
+)
+
+func Test_sourceVarName(t *testing.T) {
+	cases := map[string]string{
+		"":                   "data",
+		"a.bin":              "a_bin",
+		"fixtures/1-x y.bin": "_1_x_y_bin",
+		"dir.d/My_File":      "My_File",
+	}
+	for name, expected := range cases {
+		if result := sourceVarName(name); result != expected {
+			t.Errorf("name: %q, expected: %q, got: %q", name, expected, result)
+		}
+	}
+}
+
+func Test_outputSource(t *testing.T) {
+	type testCase struct {
+		opts     options.Options
+		data     string
+		expected string
+	}
+	cases := []testCase{
+		{options.Options{OutputMode: options.CSource, Filename: "dir/a.bin"}, "Hi\xFF",
+			"#include <stddef.h>\n\nunsigned char a_bin[] = {\n  0x48, 0x69, 0xff\n};\nsize_t a_bin_len = 3;"},
+		{options.Options{OutputMode: options.CSource, VarName: "buf", Display: options.DisplayOptions{Width: 2}}, "Hi\xFF",
+			"#include <stddef.h>\n\nunsigned char buf[] = {\n  0x48, 0x69,\n  0xff\n};\nsize_t buf_len = 3;"},
+		{options.Options{OutputMode: options.CSource}, "",
+			"#include <stddef.h>\n\nunsigned char data[1] = {0};\nsize_t data_len = 0;"},
+		{options.Options{OutputMode: options.GoSource, Display: options.DisplayOptions{Width: 2}}, "Hi\xFF",
+			"var data = []byte{\n\t0x48, 0x69,\n\t0xff,\n}"},
+		{options.Options{OutputMode: options.PythonSource, Display: options.DisplayOptions{Width: 4}}, "Hi\"\\\xFF\x00",
+			"data = (\n    b\"Hi\\x22\\x5c\"\n    b\"\\xff\\x00\"\n)"},
+		{options.Options{OutputMode: options.PythonSource}, "", "data = (\n    b\"\"\n)"},
+		{options.Options{OutputMode: options.RustSource, Filename: "a.bin"}, "Hi\xFF",
+			"pub static A_BIN: [u8; 3] = [\n    0x48, 0x69, 0xff,\n];"},
+		{options.Options{OutputMode: options.RustSource, VarName: "a_bin"}, "Hi\xFF",
+			"pub static a_bin: [u8; 3] = [\n    0x48, 0x69, 0xff,\n];"},
+		{options.Options{OutputMode: options.JavaSource, Limit: 4}, "Hi\xFF\x80\x00",
+			"byte[] data = new byte[]{\n    72, 105, -1, -128\n};"},
+	}
+	for _, c := range cases {
+		var writer strings.Builder
+		if c.opts.Limit == 0 {
+			c.opts.Limit = math.MaxInt64
+		}
+		err := outputSource(&writer, input.NewFixedLengthBufferedReader(strings.NewReader(c.data)), options.IOInfo{}, c.opts)
+		if err != nil {
+			t.Errorf("Unexpected err: %v", err)
+		}
+		if result := writer.String(); result != c.expected {
+			t.Errorf("opts: %+v, unexpected output.\nExpected:\n%s\n\ngot:\n%s", c.opts, c.expected, result)
+		}
+	}
+}
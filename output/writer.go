@@ -10,6 +10,7 @@ type FixedWidthWriter struct {
 	wrapped   io.Writer
 	lineWidth int
 	counter   int
+	newline   []byte
 }
 
 func NewFixedWidthWriter(writer io.Writer, width int) (*FixedWidthWriter, error) {
@@ -20,9 +21,20 @@ func NewFixedWidthWriter(writer io.Writer, width int) (*FixedWidthWriter, error)
 		wrapped:   writer,
 		lineWidth: width,
 		counter:   0,
+		newline:   []byte{'\n'},
 	}, nil
 }
 
+// Same as NewFixedWidthWriter but with a different line ending, ex: "\r\n".
+func NewFixedWidthNewlineWriter(writer io.Writer, width int, newline string) (*FixedWidthWriter, error) {
+	w, err := NewFixedWidthWriter(writer, width)
+	if err != nil {
+		return nil, err
+	}
+	w.newline = []byte(newline)
+	return w, nil
+}
+
 func (w *FixedWidthWriter) Write(p []byte) (n int, err error) {
 	remaining := len(p)
 	for {
@@ -30,7 +42,7 @@ func (w *FixedWidthWriter) Write(p []byte) (n int, err error) {
 			break
 		}
 		if w.counter == w.lineWidth {
-			w.wrapped.Write([]byte{'\n'})
+			w.wrapped.Write(w.newline)
 			w.counter = 0
 		}
 		// write min(remaining, width-counter), as width-counter is how much left on cur line
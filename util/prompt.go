@@ -0,0 +1,17 @@
+package util
+
+import (
+	"fmt"
+	"os"
+)
+
+// PromptForYes asks msg on stderr and returns whether the answer was y/yes.
+func PromptForYes(msg string) bool {
+	fmt.Fprintf(os.Stderr, "%s (y/n): ", msg)
+	var answer string
+	fmt.Scanln(&answer)
+	if len(answer) > 0 && (answer[0] == 'y' || answer[0] == 'Y') {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// Package z85 implements ZeroMQ's Z85 encoding (https://rfc.zeromq.org/spec/32/),
+// a base85 variant with an alphabet that is safe in source code and config files.
+// Like the spec, data must be a multiple of 4 bytes and encoded text a multiple of 5 chars.
+package z85
+
+import (
+	"fmt"
+	"io"
+)
+
+const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-:+=^!/*?&<>()[]{}@%$#"
+
+var decodeMap [256]int
+
+func init() {
+	for i := range decodeMap {
+		decodeMap[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		decodeMap[alphabet[i]] = i
+	}
+}
+
+// Encode encodes src, which must be a multiple of 4 bytes, into dst which
+// must have room for len(src)/4*5 bytes.
+func Encode(dst, src []byte) {
+	for len(src) >= 4 {
+		val := uint32(src[0])<<24 | uint32(src[1])<<16 | uint32(src[2])<<8 | uint32(src[3])
+		for i := 4; i >= 0; i-- {
+			dst[i] = alphabet[val%85]
+			val /= 85
+		}
+		src = src[4:]
+		dst = dst[5:]
+	}
+}
+
+// Decode decodes src, which must be a multiple of 5 chars, into dst which
+// must have room for len(src)/5*4 bytes.
+func Decode(dst, src []byte) error {
+	for len(src) >= 5 {
+		val := uint64(0)
+		for _, c := range src[:5] {
+			digit := decodeMap[c]
+			if digit < 0 {
+				return fmt.Errorf("Invalid Z85 char %q", c)
+			}
+			val = val*85 + uint64(digit)
+		}
+		if val > 0xFFFFFFFF {
+			return fmt.Errorf("Invalid Z85 group %q, value too large", src[:5])
+		}
+		dst[0], dst[1], dst[2], dst[3] = byte(val>>24), byte(val>>16), byte(val>>8), byte(val)
+		src = src[5:]
+		dst = dst[4:]
+	}
+	return nil
+}
+
+type encoder struct {
+	w       io.Writer
+	partial []byte
+	out     []byte
+}
+
+// NewEncoder returns a stream encoder. Close must be called to check that
+// the data written was a multiple of 4 bytes.
+func NewEncoder(w io.Writer) io.WriteCloser {
+	return &encoder{w: w}
+}
+
+func (e *encoder) Write(p []byte) (int, error) {
+	data := append(e.partial, p...)
+	whole := len(data) / 4 * 4
+	if cap(e.out) < whole/4*5 {
+		e.out = make([]byte, whole/4*5)
+	}
+	out := e.out[:whole/4*5]
+	Encode(out, data[:whole])
+	e.partial = append([]byte{}, data[whole:]...)
+	if _, err := e.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (e *encoder) Close() error {
+	if len(e.partial) > 0 {
+		return fmt.Errorf("Z85 data must be a multiple of 4 bytes, %d left over", len(e.partial))
+	}
+	return nil
+}
+
+type decoder struct {
+	r       io.Reader
+	readBuf []byte
+	partial []byte
+	out     []byte
+	err     error
+}
+
+// NewDecoder returns a stream decoder. Whitespace is ignored.
+func NewDecoder(r io.Reader) io.Reader {
+	return &decoder{r: r, readBuf: make([]byte, 1024*10)}
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 && d.err == nil {
+		n, err := d.r.Read(d.readBuf)
+		for _, c := range d.readBuf[:n] {
+			if c != ' ' && c != '\n' && c != '\r' && c != '\t' {
+				d.partial = append(d.partial, c)
+			}
+		}
+		whole := len(d.partial) / 5 * 5
+		decoded := make([]byte, whole/5*4)
+		if decodeErr := Decode(decoded, d.partial[:whole]); decodeErr != nil {
+			d.err = decodeErr
+			break
+		}
+		d.out = append(d.out, decoded...)
+		d.partial = d.partial[whole:]
+		if err != nil {
+			if err == io.EOF && len(d.partial) > 0 {
+				err = fmt.Errorf("Z85 text must be a multiple of 5 chars, %d left over", len(d.partial))
+			}
+			d.err = err
+		}
+	}
+	if len(d.out) > 0 {
+		n := copy(p, d.out)
+		d.out = d.out[n:]
+		return n, nil
+	}
+	return 0, d.err
+}
@@ -0,0 +1,53 @@
+package z85
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// from the spec
+var helloWorld = []byte{0x86, 0x4F, 0xD2, 0x6F, 0xB5, 0x59, 0xF7, 0x5B}
+
+func Test_Encoder(t *testing.T) {
+	var out bytes.Buffer
+	e := NewEncoder(&out)
+	// write in uneven pieces to make sure partial groups carry over
+	e.Write(helloWorld[:3])
+	e.Write(helloWorld[3:])
+	if err := e.Close(); err != nil {
+		t.Errorf("Unexpected err: %v", err)
+	}
+	if out.String() != "HelloWorld" {
+		t.Errorf("Unexpected output, expected: %q, got: %q", "HelloWorld", out.String())
+	}
+
+	e = NewEncoder(&out)
+	e.Write([]byte{1, 2, 3})
+	if err := e.Close(); err == nil {
+		t.Errorf("Expected err for data not a multiple of 4 bytes")
+	}
+}
+
+func Test_Decoder(t *testing.T) {
+	result, err := ioutil.ReadAll(NewDecoder(iotest.OneByteReader(strings.NewReader("Hello\n World"))))
+	if err != nil {
+		t.Errorf("Unexpected err: %v", err)
+	}
+	if !bytes.Equal(result, helloWorld) {
+		t.Errorf("Unexpected output, expected: %X, got: %X", helloWorld, result)
+	}
+
+	for input, expectedErr := range map[string]string{
+		"Hello~orld": "Invalid Z85 char '~'",
+		"HelloWorl":  "Z85 text must be a multiple of 5 chars, 4 left over",
+		"#####":      "Invalid Z85 group \"#####\", value too large",
+	} {
+		_, err := ioutil.ReadAll(NewDecoder(strings.NewReader(input)))
+		if err == nil || err.Error() != expectedErr {
+			t.Errorf("input: %q, expected err: %q, got: %v", input, expectedErr, err)
+		}
+	}
+}